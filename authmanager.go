@@ -0,0 +1,496 @@
+package paypalsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AuthorizationHonorPeriod is the time after authorization for which PayPal guarantees the funds.
+// After it the authorization can still be captured until its valid_until, but the capture may fail
+const AuthorizationHonorPeriod = 3 * 24 * time.Hour
+
+// maxCapturePercent is the percentage of the authorized amount which can be captured or reauthorized in total
+const maxCapturePercent = 115
+
+// Possible values for `state` in AuthorizationRecord
+//
+// https://developer.paypal.com/docs/api/payments/#definition-authorization
+const (
+	AuthorizationStateAuthorized        string = "authorized"
+	AuthorizationStatePartiallyCaptured string = "partially_captured"
+	AuthorizationStateCaptured          string = "captured"
+	AuthorizationStateVoided            string = "voided"
+	AuthorizationStateExpired           string = "expired"
+)
+
+// Possible values for `action` in AuthorizationAction and ExpiryAction in AuthorizationPolicy
+const (
+	AuthorizationActionReauthorize string = "reauthorize"
+	AuthorizationActionCapture     string = "capture"
+	AuthorizationActionVoid        string = "void"
+	AuthorizationActionExpire      string = "expire"
+)
+
+// ErrAuthorizationNotTracked is returned for authorizations unknown to the AuthorizationManager or already closed
+var ErrAuthorizationNotTracked = errors.New("paypalsdk: authorization not tracked")
+
+// AuthorizationRecord is the state of an authorization tracked by AuthorizationManager
+type AuthorizationRecord struct {
+	// ID is the ID of the original authorization, the record is always referred to by it
+	ID string `json:"id"`
+	// CurrentID is the ID of the reauthorization, if any, which is used to capture or void
+	CurrentID     string    `json:"current_id"`
+	Amount        Amount    `json:"amount"`
+	Captured      Amount    `json:"captured"`
+	State         string    `json:"state"`
+	Reauthorized  bool      `json:"reauthorized"`
+	AuthorizedAt  time.Time `json:"authorized_at"`
+	ValidUntil    time.Time `json:"valid_until"`
+	ParentPayment string    `json:"parent_payment,omitempty"`
+	// PendingOperation is a capture, reauthorization or void which was sent without a response from PayPal yet,
+	// PendingRequestID is its PayPal-Request-Id. Repeating the operation reuses the id, so it's never done twice
+	PendingOperation string `json:"pending_operation,omitempty"`
+	PendingRequestID string `json:"pending_request_id,omitempty"`
+}
+
+// AuthorizationStore keeps the records of open authorizations
+type AuthorizationStore interface {
+	// SaveAuthorization stores the record by its ID
+	SaveAuthorization(ctx context.Context, r *AuthorizationRecord) error
+	// LoadAuthorization returns the record of id, nil if there is none
+	LoadAuthorization(ctx context.Context, id string) (*AuthorizationRecord, error)
+	// ListAuthorizations returns all records
+	ListAuthorizations(ctx context.Context) ([]*AuthorizationRecord, error)
+	// DeleteAuthorization removes the record of id
+	DeleteAuthorization(ctx context.Context, id string) error
+}
+
+// MemoryAuthorizationStore is an AuthorizationStore which keeps records in memory
+type MemoryAuthorizationStore struct {
+	mu      sync.Mutex
+	records map[string]*AuthorizationRecord
+}
+
+// AuthorizationPolicy decides what AuthorizationManager.Process does with open authorizations
+type AuthorizationPolicy struct {
+	// Reauthorize reauthorizes an authorization once its honor period is over, so the funds are guaranteed again.
+	// PayPal allows a single reauthorization
+	Reauthorize bool
+	// ExpiryAction is done with authorizations which are valid for less than ExpiryWindow:
+	// AuthorizationActionCapture captures the remaining amount, AuthorizationActionVoid voids them,
+	// empty leaves them to expire
+	ExpiryAction string
+	ExpiryWindow time.Duration
+}
+
+// AuthorizationAction is an action done by AuthorizationManager.Process, Err is set when it failed
+type AuthorizationAction struct {
+	ID     string
+	Action string
+	Err    error
+}
+
+// AuthorizationManager tracks authorizations until they are captured, voided or expired.
+// It enforces PayPal's rules: a single reauthorization after the honor period
+// and captures of at most 115% of the authorized amount in total.
+// Operations are serialized within the manager, not across processes sharing a store
+type AuthorizationManager struct {
+	Client *Client
+	Store  AuthorizationStore
+	Policy AuthorizationPolicy
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewMemoryAuthorizationStore returns an empty MemoryAuthorizationStore
+func NewMemoryAuthorizationStore() *MemoryAuthorizationStore {
+	return &MemoryAuthorizationStore{records: make(map[string]*AuthorizationRecord)}
+}
+
+// NewAuthorizationManager returns AuthorizationManager which keeps records in memory
+func NewAuthorizationManager(c *Client, policy AuthorizationPolicy) *AuthorizationManager {
+	return &AuthorizationManager{
+		Client: c,
+		Store:  NewMemoryAuthorizationStore(),
+		Policy: policy,
+	}
+}
+
+// SaveAuthorization implements AuthorizationStore
+func (s *MemoryAuthorizationStore) SaveAuthorization(ctx context.Context, r *AuthorizationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *r
+	s.records[r.ID] = &saved
+
+	return nil
+}
+
+// LoadAuthorization implements AuthorizationStore
+func (s *MemoryAuthorizationStore) LoadAuthorization(ctx context.Context, id string) (*AuthorizationRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[id]
+	if !ok {
+		return nil, nil
+	}
+	loaded := *r
+
+	return &loaded, nil
+}
+
+// ListAuthorizations implements AuthorizationStore
+func (s *MemoryAuthorizationStore) ListAuthorizations(ctx context.Context) ([]*AuthorizationRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]*AuthorizationRecord, 0, len(s.records))
+	for _, r := range s.records {
+		loaded := *r
+		records = append(records, &loaded)
+	}
+
+	return records, nil
+}
+
+// DeleteAuthorization implements AuthorizationStore
+func (s *MemoryAuthorizationStore) DeleteAuthorization(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.records, id)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// HonorPeriodEnd returns the time when the funds of the current authorization are no longer guaranteed
+func (r *AuthorizationRecord) HonorPeriodEnd() time.Time {
+	return r.AuthorizedAt.Add(AuthorizationHonorPeriod)
+}
+
+// Track starts tracking an authorization, e.g. one of the related resources of an executed payment
+// with intent "authorize". The authorization must have ID, Amount and CreateTime
+func (m *AuthorizationManager) Track(ctx context.Context, a *Authorization) (*AuthorizationRecord, error) {
+	if a.ID == "" || a.Amount == nil || a.CreateTime == nil {
+		return nil, errors.New("paypalsdk: authorization requires id, amount and create_time to be tracked")
+	}
+	if _, err := a.Amount.Money(); err != nil {
+		return nil, err
+	}
+
+	r := &AuthorizationRecord{
+		ID:            a.ID,
+		CurrentID:     a.ID,
+		Amount:        Amount{Currency: a.Amount.Currency, Total: a.Amount.Total},
+		Captured:      NewMoney(0, a.Amount.Currency).Amount(),
+		State:         AuthorizationStateAuthorized,
+		AuthorizedAt:  *a.CreateTime,
+		ValidUntil:    a.CreateTime.Add(29 * 24 * time.Hour),
+		ParentPayment: a.ParentPayment,
+	}
+	if a.ValidUntil != nil {
+		r.ValidUntil = *a.ValidUntil
+	}
+
+	if err := m.Store.SaveAuthorization(ctx, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Due returns the open authorizations whose honor period or validity ends within d, the most urgent first
+func (m *AuthorizationManager) Due(ctx context.Context, d time.Duration) ([]*AuthorizationRecord, error) {
+	records, err := m.Store.ListAuthorizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.clock()
+	var due []*AuthorizationRecord
+	for _, r := range records {
+		if now.Add(d).After(r.ValidUntil) || (now.Before(r.HonorPeriodEnd()) && now.Add(d).After(r.HonorPeriodEnd())) {
+			due = append(due, r)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return nextDeadline(due[i], now).Before(nextDeadline(due[j], now))
+	})
+
+	return due, nil
+}
+
+// Reauthorize reauthorizes the authorized amount once the honor period is over.
+// It fails if the authorization was already reauthorized, is still in its honor period or partially captured
+func (m *AuthorizationManager) Reauthorize(ctx context.Context, id string) (*AuthorizationRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, m.reauthorize(ctx, r)
+}
+
+// Capture captures amount of the authorization, final closes it even if not all of it was captured.
+// Captures can't exceed 115% of the authorized amount in total
+func (m *AuthorizationManager) Capture(ctx context.Context, id string, amount Money, final bool) (*Capture, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.capture(ctx, r, amount, final)
+}
+
+// Void voids the authorization, so the rest of the funds are released to the payer
+func (m *AuthorizationManager) Void(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, err := m.load(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return m.void(ctx, r)
+}
+
+// Process applies the policy to all open authorizations: expired ones are dropped,
+// ones close to the end of their validity are captured or voided per ExpiryAction
+// and ones past their honor period are reauthorized if Reauthorize is set.
+// It returns the actions done, the error is only set when the store fails
+func (m *AuthorizationManager) Process(ctx context.Context) ([]AuthorizationAction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records, err := m.Store.ListAuthorizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ValidUntil.Before(records[j].ValidUntil)
+	})
+
+	var actions []AuthorizationAction
+	now := m.clock()
+	for _, r := range records {
+		switch {
+		case !now.Before(r.ValidUntil):
+			r.State = AuthorizationStateExpired
+			actions = append(actions, AuthorizationAction{ID: r.ID, Action: AuthorizationActionExpire, Err: m.Store.DeleteAuthorization(ctx, r.ID)})
+
+		case m.Policy.ExpiryAction == AuthorizationActionCapture && now.Add(m.Policy.ExpiryWindow).After(r.ValidUntil):
+			err = m.captureRemaining(ctx, r)
+			actions = append(actions, AuthorizationAction{ID: r.ID, Action: AuthorizationActionCapture, Err: err})
+
+		case m.Policy.ExpiryAction == AuthorizationActionVoid && now.Add(m.Policy.ExpiryWindow).After(r.ValidUntil):
+			actions = append(actions, AuthorizationAction{ID: r.ID, Action: AuthorizationActionVoid, Err: m.void(ctx, r)})
+
+		case m.Policy.Reauthorize && !r.Reauthorized && r.State == AuthorizationStateAuthorized && !now.Before(r.HonorPeriodEnd()):
+			actions = append(actions, AuthorizationAction{ID: r.ID, Action: AuthorizationActionReauthorize, Err: m.reauthorize(ctx, r)})
+		}
+	}
+
+	return actions, nil
+}
+
+// load returns the record of id or ErrAuthorizationNotTracked
+func (m *AuthorizationManager) load(ctx context.Context, id string) (*AuthorizationRecord, error) {
+	r, err := m.Store.LoadAuthorization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAuthorizationNotTracked, id)
+	}
+
+	return r, nil
+}
+
+// capture captures amount of r within the limit of 115% of the authorized amount and stops tracking r when it's closed
+func (m *AuthorizationManager) capture(ctx context.Context, r *AuthorizationRecord, amount Money, final bool) (*Capture, error) {
+	authorized, err := r.Amount.Money()
+	if err != nil {
+		return nil, err
+	}
+	captured, err := r.Captured.Money()
+	if err != nil {
+		return nil, err
+	}
+	if amount.IsNegative() || amount.IsZero() {
+		return nil, fmt.Errorf("%w: capture of %s %s", ErrInvalidMoney, amount, amount.Currency())
+	}
+
+	total, err := captured.Add(amount)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := authorized.Mul(maxCapturePercent)
+	if err != nil {
+		return nil, err
+	}
+	scaled, err := total.Mul(100)
+	if err != nil {
+		return nil, err
+	}
+	if scaled.Units() > limit.Units() {
+		return nil, fmt.Errorf("paypalsdk: capture of %s %s exceeds %d%% of authorization %s", amount, amount.Currency(), maxCapturePercent, r.ID)
+	}
+
+	ctx, err = m.begin(ctx, r, fmt.Sprintf("%s %s %s final=%t", AuthorizationActionCapture, amount, amount.Currency(), final))
+	if err != nil {
+		return nil, err
+	}
+
+	a := amount.Amount()
+	c, err := m.Client.CaptureAuthorizationContext(ctx, r.CurrentID, &a, final)
+	if err != nil {
+		return c, m.abort(ctx, r, err)
+	}
+
+	r.PendingOperation, r.PendingRequestID = "", ""
+	r.Captured = total.Amount()
+	if final || total.Units() >= authorized.Units() {
+		r.State = AuthorizationStateCaptured
+		return c, m.Store.DeleteAuthorization(ctx, r.ID)
+	}
+
+	r.State = AuthorizationStatePartiallyCaptured
+	return c, m.Store.SaveAuthorization(ctx, r)
+}
+
+// captureRemaining captures the authorized amount which wasn't captured yet as the final capture
+func (m *AuthorizationManager) captureRemaining(ctx context.Context, r *AuthorizationRecord) error {
+	authorized, err := r.Amount.Money()
+	if err != nil {
+		return err
+	}
+	captured, err := r.Captured.Money()
+	if err != nil {
+		return err
+	}
+	remaining, err := authorized.Sub(captured)
+	if err != nil {
+		return err
+	}
+	if remaining.IsNegative() || remaining.IsZero() {
+		return m.void(ctx, r)
+	}
+
+	_, err = m.capture(ctx, r, remaining, true)
+	return err
+}
+
+// reauthorize reauthorizes r once, after its honor period
+func (m *AuthorizationManager) reauthorize(ctx context.Context, r *AuthorizationRecord) error {
+	if r.Reauthorized {
+		return fmt.Errorf("paypalsdk: authorization %s was already reauthorized", r.ID)
+	}
+	if m.clock().Before(r.HonorPeriodEnd()) {
+		return fmt.Errorf("paypalsdk: authorization %s is in its honor period until %s", r.ID, r.HonorPeriodEnd().Format(time.RFC3339))
+	}
+	if r.State != AuthorizationStateAuthorized {
+		return fmt.Errorf("paypalsdk: authorization %s is %s and can't be reauthorized", r.ID, r.State)
+	}
+
+	ctx, err := m.begin(ctx, r, AuthorizationActionReauthorize)
+	if err != nil {
+		return err
+	}
+
+	amount := r.Amount
+	auth, err := m.Client.ReauthorizeAuthorizationContext(ctx, r.CurrentID, &amount)
+	if err != nil {
+		return m.abort(ctx, r, err)
+	}
+
+	r.PendingOperation, r.PendingRequestID = "", ""
+	r.Reauthorized = true
+	r.AuthorizedAt = m.clock()
+	if auth.ID != "" {
+		r.CurrentID = auth.ID
+	}
+	if auth.CreateTime != nil {
+		r.AuthorizedAt = *auth.CreateTime
+	}
+	if auth.ValidUntil != nil {
+		r.ValidUntil = *auth.ValidUntil
+	}
+
+	return m.Store.SaveAuthorization(ctx, r)
+}
+
+// void voids r and stops tracking it
+func (m *AuthorizationManager) void(ctx context.Context, r *AuthorizationRecord) error {
+	ctx, err := m.begin(ctx, r, AuthorizationActionVoid)
+	if err != nil {
+		return err
+	}
+
+	if _, err = m.Client.VoidAuthorizationContext(ctx, r.CurrentID); err != nil {
+		return m.abort(ctx, r, err)
+	}
+
+	r.State = AuthorizationStateVoided
+	return m.Store.DeleteAuthorization(ctx, r.ID)
+}
+
+// begin returns ctx with the request id of operation on r. A new id is saved with r before the request is sent,
+// unless r is still pending with the same operation, e.g. its response was lost, then its id is reused
+func (m *AuthorizationManager) begin(ctx context.Context, r *AuthorizationRecord, operation string) (context.Context, error) {
+	if r.PendingOperation != operation || r.PendingRequestID == "" {
+		r.PendingOperation, r.PendingRequestID = operation, NewRequestID()
+		if err := m.Store.SaveAuthorization(ctx, r); err != nil {
+			return ctx, err
+		}
+	}
+
+	return WithRequestID(ctx, r.PendingRequestID), nil
+}
+
+// abort returns err of the pending operation of r. The operation is dropped when PayPal rejected it,
+// so it can be changed and sent again, and kept when the request may have been processed
+func (m *AuthorizationManager) abort(ctx context.Context, r *AuthorizationRecord, err error) error {
+	errResp, ok := AsErrorResponse(err)
+	if !ok || errResp.Response == nil || errResp.Response.StatusCode >= 500 || errResp.Response.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+
+	r.PendingOperation, r.PendingRequestID = "", ""
+	if saveErr := m.Store.SaveAuthorization(ctx, r); saveErr != nil {
+		return fmt.Errorf("%w (the pending operation of authorization %s is kept: %v)", err, r.ID, saveErr)
+	}
+
+	return err
+}
+
+// clock returns the current time, from m.now when it's set
+func (m *AuthorizationManager) clock() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+
+	return m.now()
+}
+
+// nextDeadline returns the end of the honor period if it's still ahead, the end of validity otherwise
+func nextDeadline(r *AuthorizationRecord, now time.Time) time.Time {
+	if now.Before(r.HonorPeriodEnd()) {
+		return r.HonorPeriodEnd()
+	}
+
+	return r.ValidUntil
+}
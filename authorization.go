@@ -2,6 +2,7 @@ package paypalsdk
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 )
@@ -9,8 +10,13 @@ import (
 // GetAuthorization returns an authorization by ID
 // Endpoint: GET /v1/payments/authorization/ID
 func (c *Client) GetAuthorization(authID string) (*Authorization, error) {
+	return c.GetAuthorizationContext(context.Background(), authID)
+}
+
+// GetAuthorizationContext is like GetAuthorization but uses ctx for the request
+func (c *Client) GetAuthorizationContext(ctx context.Context, authID string) (*Authorization, error) {
 	buf := bytes.NewBuffer([]byte(""))
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID), buf)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID), buf)
 	if err != nil {
 		return &Authorization{}, err
 	}
@@ -29,18 +35,24 @@ func (c *Client) GetAuthorization(authID string) (*Authorization, error) {
 // To use this method, the original payment must have Intent set to "authorize"
 // Endpoint: POST /v1/payments/authorization/ID/capture
 func (c *Client) CaptureAuthorization(authID string, a *Amount, isFinalCapture bool) (*Capture, error) {
-	isFinalStr := "false"
-	if isFinalCapture {
-		isFinalStr = "true"
+	return c.CaptureAuthorizationContext(context.Background(), authID, a, isFinalCapture)
+}
+
+// CaptureAuthorizationContext is like CaptureAuthorization but uses ctx for the request
+func (c *Client) CaptureAuthorizationContext(ctx context.Context, authID string, a *Amount, isFinalCapture bool) (*Capture, error) {
+	type request struct {
+		Amount         *Amount `json:"amount"`
+		IsFinalCapture bool    `json:"is_final_capture"`
 	}
-	buf := bytes.NewBuffer([]byte("{\"amount\":{\"currency\":\"" + a.Currency + "\",\"total\":\"" + a.Total + "\"},\"is_final_capture\":" + isFinalStr + "}"))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID+"/capture"), buf)
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID+"/capture"), request{Amount: a, IsFinalCapture: isFinalCapture})
 	if err != nil {
 		return &Capture{}, err
 	}
 
 	capture := &Capture{}
 
+	setRequestID(req)
 	err = c.SendWithAuth(req, capture)
 	if err != nil {
 		return capture, err
@@ -52,11 +64,17 @@ func (c *Client) CaptureAuthorization(authID string, a *Amount, isFinalCapture b
 // VoidAuthorization voids a previously authorized payment
 // Endpoint: POST /v1/payments/authorization/ID/void
 func (c *Client) VoidAuthorization(authID string) (*Authorization, error) {
+	return c.VoidAuthorizationContext(context.Background(), authID)
+}
+
+// VoidAuthorizationContext is like VoidAuthorization but uses ctx for the request
+func (c *Client) VoidAuthorizationContext(ctx context.Context, authID string) (*Authorization, error) {
 	buf := bytes.NewBuffer([]byte(""))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID+"/void"), buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID+"/void"), buf)
 	if err != nil {
 		return &Authorization{}, err
 	}
+	setRequestID(req)
 
 	auth := &Authorization{}
 
@@ -69,17 +87,26 @@ func (c *Client) VoidAuthorization(authID string) (*Authorization, error) {
 }
 
 // ReauthorizeAuthorization reauthorize a Paypal account payment.
-// PayPal recommends to reauthorize payment after ~3 days
+// PayPal recommends to reauthorize payment after ~3 days, AuthorizationManager can keep track of it
 // Endpoint: POST /v1/payments/authorization/ID/reauthorize
 func (c *Client) ReauthorizeAuthorization(authID string, a *Amount) (*Authorization, error) {
-	buf := bytes.NewBuffer([]byte("{\"amount\":{\"currency\":\"" + a.Currency + "\",\"total\":\"" + a.Total + "\"}}"))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID+"/reauthorize"), buf)
+	return c.ReauthorizeAuthorizationContext(context.Background(), authID, a)
+}
+
+// ReauthorizeAuthorizationContext is like ReauthorizeAuthorization but uses ctx for the request
+func (c *Client) ReauthorizeAuthorizationContext(ctx context.Context, authID string, a *Amount) (*Authorization, error) {
+	type request struct {
+		Amount *Amount `json:"amount"`
+	}
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/authorization/"+authID+"/reauthorize"), request{Amount: a})
 	if err != nil {
 		return &Authorization{}, err
 	}
 
 	auth := &Authorization{}
 
+	setRequestID(req)
 	err = c.SendWithAuth(req, auth)
 	if err != nil {
 		return auth, err
@@ -0,0 +1,307 @@
+package paypalsdk
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CreateBillingPlan creates a billing plan in Paypal
+// The plan is created in CREATED state, it must be activated with ActivatePlan before use
+// Endpoint: POST /v1/payments/billing-plans
+func (c *Client) CreateBillingPlan(plan Plan) (*Plan, error) {
+	return c.CreateBillingPlanContext(context.Background(), plan)
+}
+
+// CreateBillingPlanContext is like CreateBillingPlan but uses ctx for the request
+func (c *Client) CreateBillingPlanContext(ctx context.Context, plan Plan) (*Plan, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/billing-plans"), plan)
+	if err != nil {
+		return &Plan{}, err
+	}
+
+	response := &Plan{}
+
+	setRequestID(req)
+	err = c.SendWithAuth(req, response)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// GetBillingPlan returns a billing plan by ID
+// Endpoint: GET /v1/payments/billing-plans/ID
+func (c *Client) GetBillingPlan(planID string) (*Plan, error) {
+	return c.GetBillingPlanContext(context.Background(), planID)
+}
+
+// GetBillingPlanContext is like GetBillingPlan but uses ctx for the request
+func (c *Client) GetBillingPlanContext(ctx context.Context, planID string) (*Plan, error) {
+	plan := &Plan{}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/billing-plans/"+planID), nil)
+	if err != nil {
+		return plan, err
+	}
+
+	err = c.SendWithAuth(req, plan)
+	if err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+// ListBillingPlans returns billing plans, by default the first page of 10 plans in CREATED state
+// Endpoint: GET /v1/payments/billing-plans
+func (c *Client) ListBillingPlans(bpf *BillingPlansFilter) (*BillingPlans, error) {
+	return c.ListBillingPlansContext(context.Background(), bpf)
+}
+
+// ListBillingPlansContext is like ListBillingPlans but uses ctx for the request
+func (c *Client) ListBillingPlansContext(ctx context.Context, bpf *BillingPlansFilter) (*BillingPlans, error) {
+	q := url.Values{}
+	if bpf != nil {
+		if bpf.Status != "" {
+			q.Set("status", bpf.Status)
+		}
+		if bpf.Page > 0 {
+			q.Set("page", strconv.Itoa(bpf.Page))
+		}
+		if bpf.PageSize > 0 {
+			q.Set("page_size", strconv.Itoa(bpf.PageSize))
+		}
+		if bpf.TotalRequired {
+			q.Set("total_required", "yes")
+		}
+	}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s/v1/payments/billing-plans?%s", c.APIBase, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := BillingPlans{}
+	err = c.SendWithAuth(req, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// UpdateBillingPlan applies JSON patch operations to a billing plan
+// Endpoint: PATCH /v1/payments/billing-plans/ID
+func (c *Client) UpdateBillingPlan(planID string, patch Patch) error {
+	return c.UpdateBillingPlanContext(context.Background(), planID, patch)
+}
+
+// UpdateBillingPlanContext is like UpdateBillingPlan but uses ctx for the request
+func (c *Client) UpdateBillingPlanContext(ctx context.Context, planID string, patch Patch) error {
+	return c.sendPatch(ctx, fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/billing-plans/"+planID), patch, nil)
+}
+
+// ActivatePlan changes state of a billing plan to ACTIVE, so agreements can be created for it
+// Endpoint: PATCH /v1/payments/billing-plans/ID
+func (c *Client) ActivatePlan(planID string) error {
+	return c.ActivatePlanContext(context.Background(), planID)
+}
+
+// ActivatePlanContext is like ActivatePlan but uses ctx for the request
+func (c *Client) ActivatePlanContext(ctx context.Context, planID string) error {
+	return c.setPlanState(ctx, planID, PlanStateActive)
+}
+
+// DeactivatePlan changes state of a billing plan to INACTIVE
+// Endpoint: PATCH /v1/payments/billing-plans/ID
+func (c *Client) DeactivatePlan(planID string) error {
+	return c.DeactivatePlanContext(context.Background(), planID)
+}
+
+// DeactivatePlanContext is like DeactivatePlan but uses ctx for the request
+func (c *Client) DeactivatePlanContext(ctx context.Context, planID string) error {
+	return c.setPlanState(ctx, planID, PlanStateInactive)
+}
+
+// setPlanState replaces state of a billing plan
+func (c *Client) setPlanState(ctx context.Context, planID string, state string) error {
+	return c.UpdateBillingPlanContext(ctx, planID, Patch{}.Replace("/", PlanState{State: state}))
+}
+
+// CreateBillingAgreement creates an agreement for an active billing plan, only Id of a.Plan is required.
+// The payer must approve the agreement at ApprovalURL of the result,
+// PayPal then redirects back with a token for ExecuteApprovedAgreement
+// Endpoint: POST /v1/payments/billing-agreements
+func (c *Client) CreateBillingAgreement(a Agreement) (*Agreement, error) {
+	return c.CreateBillingAgreementContext(context.Background(), a)
+}
+
+// CreateBillingAgreementContext is like CreateBillingAgreement but uses ctx for the request
+func (c *Client) CreateBillingAgreementContext(ctx context.Context, a Agreement) (*Agreement, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/billing-agreements"), a)
+	if err != nil {
+		return &Agreement{}, err
+	}
+
+	response := &Agreement{}
+
+	setRequestID(req)
+	err = c.SendWithAuth(req, response)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// ExecuteApprovedAgreement executes an agreement approved by the payer, token is passed by PayPal to the return URL
+// Endpoint: POST /v1/payments/billing-agreements/token/agreement-execute
+func (c *Client) ExecuteApprovedAgreement(token string) (*Agreement, error) {
+	return c.ExecuteApprovedAgreementContext(context.Background(), token)
+}
+
+// ExecuteApprovedAgreementContext is like ExecuteApprovedAgreement but uses ctx for the request
+func (c *Client) ExecuteApprovedAgreementContext(ctx context.Context, token string) (*Agreement, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/billing-agreements/"+token+"/agreement-execute"), nil)
+	if err != nil {
+		return &Agreement{}, err
+	}
+
+	response := &Agreement{}
+
+	setRequestID(req)
+	err = c.SendWithAuth(req, response)
+	if err != nil {
+		return response, err
+	}
+
+	if response.Id == "" {
+		return response, fmt.Errorf("paypalsdk: unable to execute agreement with token = %s", token)
+	}
+
+	return response, nil
+}
+
+// GetBillingAgreement returns an agreement by ID
+// Endpoint: GET /v1/payments/billing-agreements/ID
+func (c *Client) GetBillingAgreement(agreementID string) (*Agreement, error) {
+	return c.GetBillingAgreementContext(context.Background(), agreementID)
+}
+
+// GetBillingAgreementContext is like GetBillingAgreement but uses ctx for the request
+func (c *Client) GetBillingAgreementContext(ctx context.Context, agreementID string) (*Agreement, error) {
+	agreement := &Agreement{}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/billing-agreements/"+agreementID), nil)
+	if err != nil {
+		return agreement, err
+	}
+
+	err = c.SendWithAuth(req, agreement)
+	if err != nil {
+		return agreement, err
+	}
+
+	return agreement, nil
+}
+
+// SuspendBillingAgreement suspends an active agreement
+// Endpoint: POST /v1/payments/billing-agreements/ID/suspend
+func (c *Client) SuspendBillingAgreement(agreementID string, note string) error {
+	return c.SuspendBillingAgreementContext(context.Background(), agreementID, note)
+}
+
+// SuspendBillingAgreementContext is like SuspendBillingAgreement but uses ctx for the request
+func (c *Client) SuspendBillingAgreementContext(ctx context.Context, agreementID string, note string) error {
+	return c.changeAgreement(ctx, agreementID, "suspend", AgreementStateDescriptor{Note: note})
+}
+
+// ReactivateBillingAgreement reactivates a suspended agreement
+// Endpoint: POST /v1/payments/billing-agreements/ID/re-activate
+func (c *Client) ReactivateBillingAgreement(agreementID string, note string) error {
+	return c.ReactivateBillingAgreementContext(context.Background(), agreementID, note)
+}
+
+// ReactivateBillingAgreementContext is like ReactivateBillingAgreement but uses ctx for the request
+func (c *Client) ReactivateBillingAgreementContext(ctx context.Context, agreementID string, note string) error {
+	return c.changeAgreement(ctx, agreementID, "re-activate", AgreementStateDescriptor{Note: note})
+}
+
+// CancelBillingAgreement cancels an agreement
+// Endpoint: POST /v1/payments/billing-agreements/ID/cancel
+func (c *Client) CancelBillingAgreement(agreementID string, note string) error {
+	return c.CancelBillingAgreementContext(context.Background(), agreementID, note)
+}
+
+// CancelBillingAgreementContext is like CancelBillingAgreement but uses ctx for the request
+func (c *Client) CancelBillingAgreementContext(ctx context.Context, agreementID string, note string) error {
+	return c.changeAgreement(ctx, agreementID, "cancel", AgreementStateDescriptor{Note: note})
+}
+
+// SetAgreementBalance sets the outstanding balance of an agreement
+// Endpoint: POST /v1/payments/billing-agreements/ID/set-balance
+func (c *Client) SetAgreementBalance(agreementID string, balance *Currency) error {
+	return c.SetAgreementBalanceContext(context.Background(), agreementID, balance)
+}
+
+// SetAgreementBalanceContext is like SetAgreementBalance but uses ctx for the request
+func (c *Client) SetAgreementBalanceContext(ctx context.Context, agreementID string, balance *Currency) error {
+	return c.changeAgreement(ctx, agreementID, "set-balance", balance)
+}
+
+// BillAgreementBalance bills the outstanding balance of an agreement, nil amount bills the whole balance
+// Endpoint: POST /v1/payments/billing-agreements/ID/bill-balance
+func (c *Client) BillAgreementBalance(agreementID string, note string, amount *Currency) error {
+	return c.BillAgreementBalanceContext(context.Background(), agreementID, note, amount)
+}
+
+// BillAgreementBalanceContext is like BillAgreementBalance but uses ctx for the request
+func (c *Client) BillAgreementBalanceContext(ctx context.Context, agreementID string, note string, amount *Currency) error {
+	return c.changeAgreement(ctx, agreementID, "bill-balance", AgreementStateDescriptor{Note: note, Amount: amount})
+}
+
+// ListAgreementTransactions returns transactions of an agreement between two dates
+// Endpoint: GET /v1/payments/billing-agreements/ID/transactions?start_date=yyyy-mm-dd&end_date=yyyy-mm-dd
+func (c *Client) ListAgreementTransactions(agreementID string, startDate time.Time, endDate time.Time) (*AgreementTransactions, error) {
+	return c.ListAgreementTransactionsContext(context.Background(), agreementID, startDate, endDate)
+}
+
+// ListAgreementTransactionsContext is like ListAgreementTransactions but uses ctx for the request
+func (c *Client) ListAgreementTransactionsContext(ctx context.Context, agreementID string, startDate time.Time, endDate time.Time) (*AgreementTransactions, error) {
+	q := url.Values{}
+	q.Set("start_date", startDate.Format("2006-01-02"))
+	q.Set("end_date", endDate.Format("2006-01-02"))
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s/v1/payments/billing-agreements/%s/transactions?%s", c.APIBase, agreementID, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := AgreementTransactions{}
+	err = c.SendWithAuth(req, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ApprovalURL returns the URL where the payer approves the agreement, or empty string if there is none
+func (a *Agreement) ApprovalURL() string {
+	return a.Links.ApprovalURL()
+}
+
+// changeAgreement posts payload to the given action of an agreement
+func (c *Client) changeAgreement(ctx context.Context, agreementID string, action string, payload interface{}) error {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s/v1/payments/billing-agreements/%s/%s", c.APIBase, agreementID, action), payload)
+	if err != nil {
+		return err
+	}
+
+	setRequestID(req)
+	return c.SendWithAuth(req, nil)
+}
@@ -0,0 +1,58 @@
+package paypalsdk
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetCapture returns a capture by ID
+// Endpoint: GET /v1/payments/capture/ID
+func (c *Client) GetCapture(captureID string) (*Capture, error) {
+	return c.GetCaptureContext(context.Background(), captureID)
+}
+
+// GetCaptureContext is like GetCapture but uses ctx for the request
+func (c *Client) GetCaptureContext(ctx context.Context, captureID string) (*Capture, error) {
+	capture := &Capture{}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/capture/"+captureID), nil)
+	if err != nil {
+		return capture, err
+	}
+
+	err = c.SendWithAuth(req, capture)
+	if err != nil {
+		return capture, err
+	}
+
+	return capture, nil
+}
+
+// RefundCapture refunds a captured payment of an authorization or an order.
+// Pass nil for a full refund, or RefundRequest with Amount for a partial one
+// Endpoint: POST /v1/payments/capture/ID/refund
+func (c *Client) RefundCapture(captureID string, r *RefundRequest) (*Refund, error) {
+	return c.RefundCaptureContext(context.Background(), captureID, r)
+}
+
+// RefundCaptureContext is like RefundCapture but uses ctx for the request
+func (c *Client) RefundCaptureContext(ctx context.Context, captureID string, r *RefundRequest) (*Refund, error) {
+	if r == nil {
+		r = &RefundRequest{}
+	}
+
+	refund := &Refund{}
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/capture/"+captureID+"/refund"), r)
+	if err != nil {
+		return refund, err
+	}
+
+	setRequestID(req)
+	err = c.SendWithAuth(req, refund)
+	if err != nil {
+		return refund, err
+	}
+
+	return refund, nil
+}
@@ -0,0 +1,260 @@
+package paypalsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCheckoutNotFound is returned for a return or cancel request of an unknown or expired checkout
+	ErrCheckoutNotFound = errors.New("paypalsdk: checkout not found")
+	// ErrCheckoutCancelled is returned by Complete when the payer cancelled the payment on PayPal
+	ErrCheckoutCancelled = errors.New("paypalsdk: checkout cancelled by payer")
+	// ErrCheckoutMismatch is returned when the executed payment isn't the one which was requested
+	ErrCheckoutMismatch = errors.New("paypalsdk: checkout verification failed")
+)
+
+// CheckoutSession is the state of a checkout between Checkout.Begin and Checkout.Complete
+type CheckoutSession struct {
+	Token     string    `json:"token"`
+	PaymentID string    `json:"payment_id"`
+	RequestID string    `json:"request_id"`
+	Amounts   []Amount  `json:"amounts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CheckoutStore keeps checkout sessions while the payer is on PayPal,
+// use a shared store (e.g. Redis or a database) when the return URL can be served by another process
+type CheckoutStore interface {
+	// SaveCheckout stores the session by its token
+	SaveCheckout(ctx context.Context, s *CheckoutSession) error
+	// GetCheckout returns the session of token, nil if there is none
+	GetCheckout(ctx context.Context, token string) (*CheckoutSession, error)
+	// DeleteCheckout removes the session of token
+	DeleteCheckout(ctx context.Context, token string) error
+}
+
+// MemoryCheckoutStore is a CheckoutStore which keeps sessions in memory for TTL
+type MemoryCheckoutStore struct {
+	TTL time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*CheckoutSession
+}
+
+// Checkout runs the redirect checkout flow: Begin creates the payment and returns the URL the payer
+// must be redirected to, Complete handles the request to the return URL, executes the payment
+// and verifies it against the created one
+type Checkout struct {
+	Client *Client
+	Store  CheckoutStore
+}
+
+// NewMemoryCheckoutStore returns MemoryCheckoutStore which keeps sessions for ttl.
+// PayPal approval tokens expire after 3 hours
+func NewMemoryCheckoutStore(ttl time.Duration) *MemoryCheckoutStore {
+	return &MemoryCheckoutStore{TTL: ttl, sessions: make(map[string]*CheckoutSession)}
+}
+
+// NewCheckout returns Checkout which creates payments with c and keeps sessions in memory for 3 hours
+func NewCheckout(c *Client) *Checkout {
+	return &Checkout{
+		Client: c,
+		Store:  NewMemoryCheckoutStore(3 * time.Hour),
+	}
+}
+
+// SaveCheckout implements CheckoutStore
+func (s *MemoryCheckoutStore) SaveCheckout(ctx context.Context, session *CheckoutSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for token, saved := range s.sessions {
+		if now.Sub(saved.CreatedAt) > s.TTL {
+			delete(s.sessions, token)
+		}
+	}
+	if s.sessions == nil {
+		s.sessions = make(map[string]*CheckoutSession)
+	}
+	saved := *session
+	saved.Amounts = append([]Amount(nil), session.Amounts...)
+	s.sessions[session.Token] = &saved
+
+	return nil
+}
+
+// GetCheckout implements CheckoutStore
+func (s *MemoryCheckoutStore) GetCheckout(ctx context.Context, token string) (*CheckoutSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[token]
+	if !ok || time.Since(session.CreatedAt) > s.TTL {
+		return nil, nil
+	}
+	loaded := *session
+	loaded.Amounts = append([]Amount(nil), session.Amounts...)
+
+	return &loaded, nil
+}
+
+// DeleteCheckout implements CheckoutStore
+func (s *MemoryCheckoutStore) DeleteCheckout(ctx context.Context, token string) error {
+	s.mu.Lock()
+	delete(s.sessions, token)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Begin creates the payment and stores its session. The payment must have RedirectURLs,
+// its payment method is set to "paypal" when Payer is nil.
+// It returns the approval URL the payer must be redirected to and the token of the checkout
+func (co *Checkout) Begin(payment Payment) (string, string, error) {
+	return co.BeginContext(context.Background(), payment)
+}
+
+// BeginContext is like Begin but uses ctx for the requests
+func (co *Checkout) BeginContext(ctx context.Context, payment Payment) (string, string, error) {
+	if payment.RedirectURLs == nil || payment.RedirectURLs.ReturnURL == "" || payment.RedirectURLs.CancelURL == "" {
+		return "", "", errors.New("paypalsdk: checkout payment requires return and cancel URLs")
+	}
+	if payment.Payer == nil {
+		payment.Payer = &Payer{PaymentMethod: "paypal"}
+	}
+	if err := payment.Validate(); err != nil {
+		return "", "", err
+	}
+
+	created, err := co.Client.CreatePaymentContext(ctx, payment)
+	if err != nil {
+		return "", "", err
+	}
+	if created.Payment == nil || created.ID == "" {
+		return "", "", errors.New("paypalsdk: payment created without id")
+	}
+
+	approvalURL := created.Links.ApprovalURL()
+	u, err := url.Parse(approvalURL)
+	if err != nil || approvalURL == "" || u.Query().Get("token") == "" {
+		return "", "", fmt.Errorf("paypalsdk: payment %s has no approval URL with token", created.ID)
+	}
+
+	session := &CheckoutSession{
+		Token:     u.Query().Get("token"),
+		PaymentID: created.ID,
+		RequestID: NewRequestID(),
+		CreatedAt: time.Now(),
+	}
+	for _, t := range payment.Transactions {
+		session.Amounts = append(session.Amounts, *t.Amount)
+	}
+	if err = co.Store.SaveCheckout(ctx, session); err != nil {
+		return "", "", err
+	}
+
+	return approvalURL, session.Token, nil
+}
+
+// Complete handles the request to the return URL: it executes the payment of the session
+// with paymentId and PayerID of the query and verifies the state and amounts of the executed payment.
+// A request without PayerID, e.g. to the cancel URL, cancels the checkout with ErrCheckoutCancelled.
+// The session is kept when the execution fails, so Complete can be called again for the same request
+func (co *Checkout) Complete(r *http.Request) (*ExecuteResponse, error) {
+	q := r.URL.Query()
+	ctx := r.Context()
+
+	session, err := co.session(ctx, q.Get("token"))
+	if err != nil {
+		return nil, err
+	}
+
+	payerID := q.Get("PayerID")
+	if payerID == "" {
+		if err = co.Store.DeleteCheckout(ctx, session.Token); err != nil {
+			return nil, err
+		}
+		return nil, ErrCheckoutCancelled
+	}
+	if paymentID := q.Get("paymentId"); paymentID != session.PaymentID {
+		return nil, fmt.Errorf("%w: payment %s, expecting %s", ErrCheckoutMismatch, paymentID, session.PaymentID)
+	}
+
+	// the same request id for every attempt, so a payment is never executed twice
+	executed, err := co.Client.ExecuteApprovedPaymentContext(WithRequestID(ctx, session.RequestID), session.PaymentID, payerID)
+	if err != nil {
+		return executed, err
+	}
+
+	// the payment is executed, so the session is done even when the verification fails
+	verifyErr := verifyCheckout(session, executed)
+	if err = co.Store.DeleteCheckout(ctx, session.Token); err != nil && verifyErr == nil {
+		return executed, err
+	}
+
+	return executed, verifyErr
+}
+
+// Cancel handles the request to the cancel URL and removes the session of its token
+func (co *Checkout) Cancel(r *http.Request) error {
+	session, err := co.session(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		return err
+	}
+
+	return co.Store.DeleteCheckout(r.Context(), session.Token)
+}
+
+// session returns the stored session of token
+func (co *Checkout) session(ctx context.Context, token string) (*CheckoutSession, error) {
+	if token == "" {
+		return nil, ErrCheckoutNotFound
+	}
+
+	session, err := co.Store.GetCheckout(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrCheckoutNotFound
+	}
+
+	return session, nil
+}
+
+// verifyCheckout checks that the executed payment is approved and has the amounts of the session
+func verifyCheckout(session *CheckoutSession, executed *ExecuteResponse) error {
+	if executed.ID != session.PaymentID {
+		return fmt.Errorf("%w: executed payment %s, expecting %s", ErrCheckoutMismatch, executed.ID, session.PaymentID)
+	}
+	if executed.State != "approved" {
+		return fmt.Errorf("%w: payment %s is %s", ErrCheckoutMismatch, executed.ID, executed.State)
+	}
+	if len(executed.Transactions) != len(session.Amounts) {
+		return fmt.Errorf("%w: payment %s has %d transactions, expecting %d", ErrCheckoutMismatch, executed.ID, len(executed.Transactions), len(session.Amounts))
+	}
+
+	for i, t := range executed.Transactions {
+		want, err := session.Amounts[i].Money()
+		if err != nil {
+			return err
+		}
+		if t.Amount == nil {
+			return fmt.Errorf("%w: transactions[%d] of payment %s has no amount", ErrCheckoutMismatch, i, executed.ID)
+		}
+		got, err := t.Amount.Money()
+		if err != nil || got != want {
+			return fmt.Errorf("%w: transactions[%d] of payment %s is %s %s, expecting %s %s",
+				ErrCheckoutMismatch, i, executed.ID, t.Amount.Total, t.Amount.Currency, want, want.Currency())
+		}
+	}
+
+	return nil
+}
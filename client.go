@@ -2,6 +2,7 @@ package paypalsdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,29 +10,49 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"time"
 )
 
 // NewClient returns new Client struct
 // APIBase is a base API URL, for testing you can use paypalsdk.APIBaseSandBox
-func NewClient(clientID string, secret string, APIBase string) (*Client, error) {
-	if clientID == "" || secret == "" || APIBase == "" {
-		return nil, errors.New("ClientID, Secret and APIBase are required to create a Client")
-	}
-
-	return &Client{
+// opts can be used to customize the client, e.g. NewClient(id, secret, APIBase, WithTimeout(10*time.Second))
+func NewClient(clientID string, secret string, APIBase string, opts ...ClientOption) (*Client, error) {
+	c := &Client{
 		client:   &http.Client{},
 		ClientID: clientID,
 		Secret:   secret,
 		APIBase:  APIBase,
-	}, nil
+		locale:   "en_US",
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.ClientID == "" || c.Secret == "" || c.APIBase == "" {
+		return nil, errors.New("ClientID, Secret and APIBase are required to create a Client")
+	}
+
+	if c.timeout > 0 {
+		hc := *c.client
+		hc.Timeout = c.timeout
+		c.client = &hc
+	}
+
+	return c, nil
 }
 
 // GetAccessToken returns struct of TokenResponse
 // No need to call SetAccessToken to apply new access token for current Client
 // Endpoint: POST /v1/oauth2/token
 func (c *Client) GetAccessToken() (*TokenResponse, error) {
+	return c.GetAccessTokenContext(context.Background())
+}
+
+// GetAccessTokenContext is like GetAccessToken but uses ctx for the request
+func (c *Client) GetAccessTokenContext(ctx context.Context) (*TokenResponse, error) {
 	buf := bytes.NewBuffer([]byte("grant_type=client_credentials"))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/oauth2/token"), buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/oauth2/token"), buf)
 	if err != nil {
 		return &TokenResponse{}, err
 	}
@@ -40,21 +61,47 @@ func (c *Client) GetAccessToken() (*TokenResponse, error) {
 	req.Header.Set("Content-type", "application/x-www-form-urlencoded")
 
 	t := TokenResponse{}
+	issuedAt := time.Now()
 	err = c.Send(req, &t)
 
 	// Set Token fur current Client
 	if t.Token != "" {
+		t.ExpiresAt = issuedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+		c.tokenMu.Lock()
 		c.Token = &t
+		c.tokenMu.Unlock()
+
+		// The store is only a cache, the token is usable even if it can't be saved
+		if c.TokenStore != nil {
+			c.TokenStore.SetToken(ctx, &t)
+		}
 	}
 
 	return &t, err
 }
 
 // SetAccessToken sets saved token to current client
+// The expiry of such token is unknown, so it's replaced only when the API rejects it
 func (c *Client) SetAccessToken(token string) error {
+	c.tokenMu.Lock()
 	c.Token = &TokenResponse{
 		Token: token,
 	}
+	c.tokenMu.Unlock()
+
+	return nil
+}
+
+// SetAccessTokenWithExpiry sets saved token with its expiry time to current client,
+// the token is refreshed automatically when it's about to expire
+func (c *Client) SetAccessTokenWithExpiry(token string, expiresAt time.Time) error {
+	c.tokenMu.Lock()
+	c.Token = &TokenResponse{
+		Token:     token,
+		ExpiresIn: int64(time.Until(expiresAt) / time.Second),
+		ExpiresAt: expiresAt,
+	}
+	c.tokenMu.Unlock()
 
 	return nil
 }
@@ -69,27 +116,59 @@ func (c *Client) SetLog(log io.Writer) error {
 // Send makes a request to the API, the response body will be
 // unmarshaled into v, or if v is an io.Writer, the response will
 // be written to it without decoding
+// If c.RetryPolicy is set, failed requests are retried and errors are returned as *RetryError
 func (c *Client) Send(req *http.Request, v interface{}) error {
 	var (
-		err  error
-		resp *http.Response
-		data []byte
+		err      error
+		resp     *http.Response
+		data     []byte
+		attempts int
 	)
 
 	// Set default headers
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Language", "en_US")
+	if c.locale != "" {
+		req.Header.Set("Accept-Language", c.locale)
+	} else {
+		req.Header.Set("Accept-Language", "en_US")
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.partnerAttributionID != "" {
+		req.Header.Set("PayPal-Partner-Attribution-Id", c.partnerAttributionID)
+	}
 
 	// Default values for headers
 	if req.Header.Get("Content-type") == "" {
 		req.Header.Set("Content-type", "application/json")
 	}
 
-	resp, err = c.client.Do(req)
-	c.log(req, resp)
+	for attempts = 1; ; attempts++ {
+		resp, err = c.client.Do(req)
+		c.log(req, resp)
+
+		if !c.RetryPolicy.shouldRetry(req, resp, err, attempts) {
+			break
+		}
+
+		wait := c.RetryPolicy.backoff(attempts, resp)
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err = sleepContext(req.Context(), wait); err != nil {
+			return c.retryError(attempts, err)
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return c.retryError(attempts, err)
+			}
+		}
+	}
 
 	if err != nil {
-		return err
+		return c.retryError(attempts, err)
 	}
 	defer resp.Body.Close()
 
@@ -100,8 +179,11 @@ func (c *Client) Send(req *http.Request, v interface{}) error {
 		if err == nil && len(data) > 0 {
 			json.Unmarshal(data, errResp)
 		}
+		if errResp.DebugID == "" {
+			errResp.DebugID = resp.Header.Get("PayPal-Debug-Id")
+		}
 
-		return errResp
+		return c.retryError(attempts, errResp)
 	}
 
 	if v != nil {
@@ -118,28 +200,58 @@ func (c *Client) Send(req *http.Request, v interface{}) error {
 	return nil
 }
 
+// SendContext is like Send but replaces the context of req with ctx,
+// so the call is cancelled as soon as ctx is done
+func (c *Client) SendContext(ctx context.Context, req *http.Request, v interface{}) error {
+	return c.Send(req.WithContext(ctx), v)
+}
+
 // SendWithAuth makes a request to the API and apply OAuth2 header automatically.
-// If the access token soon to be expired or already expired, it will try to get a new one before
-// making the main request
+// If there is no access token yet, or it soon to be expired or already expired, it will try to get a new one before
+// making the main request, so calling GetAccessToken first is not required
 // client.Token will be updated when changed
+// The token refresh shares the context of req, so cancelling it aborts the refresh too
+// Concurrent calls share a single token refresh. If the API rejects the token with 401,
+// a new token is fetched and the request is sent once again
 func (c *Client) SendWithAuth(req *http.Request, v interface{}) error {
-	if c.Token != nil {
-		if c.Token.ExpiresIn < RequestNewTokenBeforeExpiresIn {
-			// c.Token will be updated in GetAccessToken call
-			if _, err := c.GetAccessToken(); err != nil {
-				return err
-			}
-		}
+	token, err := c.validToken(req.Context(), nil)
+	if err != nil {
+		return err
+	}
 
-		req.Header.Set("Authorization", "Bearer "+c.Token.Token)
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	err = c.Send(req, v)
+	if !isTokenRejected(err) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return err
 	}
 
+	// c.Token will be replaced unless another request has done it already
+	if token, err = c.validToken(req.Context(), token); err != nil {
+		return err
+	}
+	if req.GetBody != nil {
+		if req.Body, err = req.GetBody(); err != nil {
+			return err
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token.Token)
 	return c.Send(req, v)
 }
 
+// SendWithAuthContext is like SendWithAuth but replaces the context of req with ctx
+func (c *Client) SendWithAuthContext(ctx context.Context, req *http.Request, v interface{}) error {
+	return c.SendWithAuth(req.WithContext(ctx), v)
+}
+
 // NewRequest constructs a request
 // Convert payload to a JSON
 func (c *Client) NewRequest(method, url string, payload interface{}) (*http.Request, error) {
+	return c.NewRequestContext(context.Background(), method, url, payload)
+}
+
+// NewRequestContext is like NewRequest but binds the request to ctx
+func (c *Client) NewRequestContext(ctx context.Context, method, url string, payload interface{}) (*http.Request, error) {
 	var buf io.Reader
 	if payload != nil {
 		var b []byte
@@ -149,7 +261,16 @@ func (c *Client) NewRequest(method, url string, payload interface{}) (*http.Requ
 		}
 		buf = bytes.NewBuffer(b)
 	}
-	return http.NewRequest(method, url, buf)
+	return http.NewRequestWithContext(ctx, method, url, buf)
+}
+
+// retryError wraps err into RetryError when retries are enabled
+func (c *Client) retryError(attempts int, err error) error {
+	if c.RetryPolicy == nil {
+		return err
+	}
+
+	return &RetryError{Attempts: attempts, Err: err}
 }
 
 // log will dump request and response to the log file
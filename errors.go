@@ -0,0 +1,98 @@
+package paypalsdk
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Names of common API errors
+//
+// https://developer.paypal.com/docs/api/payments/#errors
+const (
+	ErrorNameValidation             = "VALIDATION_ERROR"
+	ErrorNameInstrumentDeclined     = "INSTRUMENT_DECLINED"
+	ErrorNameCreditCardRefused      = "CREDIT_CARD_REFUSED"
+	ErrorNameCreditCardCVVFailed    = "CREDIT_CARD_CVV_CHECK_FAILED"
+	ErrorNameExpiredCreditCard      = "EXPIRED_CREDIT_CARD"
+	ErrorNameDuplicateRequestID     = "DUPLICATE_REQUEST_ID"
+	ErrorNameDuplicateTransaction   = "DUPLICATE_TRANSACTION"
+	ErrorNameDuplicateSenderBatchID = "DUPLICATE_SENDER_BATCH_ID"
+	ErrorNameRateLimitReached       = "RATE_LIMIT_REACHED"
+	ErrorNameAuthenticationFailure  = "AUTHENTICATION_FAILURE"
+	ErrorNameNotAuthorized          = "NOT_AUTHORIZED"
+)
+
+// AsErrorResponse returns the ErrorResponse err is or wraps (e.g. in RetryError)
+func AsErrorResponse(err error) (*ErrorResponse, bool) {
+	var errResp *ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp, true
+	}
+
+	return nil, false
+}
+
+// IsValidationError reports whether the request was rejected because of invalid fields, see ErrorResponse.Details,
+// or err is ValidationErrors of a client-side check like Payment.Validate
+func IsValidationError(err error) bool {
+	var validationErrs ValidationErrors
+	return hasErrorName(err, ErrorNameValidation) || errors.As(err, &validationErrs)
+}
+
+// IsInstrumentDeclined reports whether the card or other funding instrument of the payer was declined
+func IsInstrumentDeclined(err error) bool {
+	return hasErrorName(err, ErrorNameInstrumentDeclined, ErrorNameCreditCardRefused, ErrorNameCreditCardCVVFailed, ErrorNameExpiredCreditCard)
+}
+
+// IsDuplicateRequest reports whether the request was already processed, e.g. with the same PayPal-Request-Id
+func IsDuplicateRequest(err error) bool {
+	return hasErrorName(err, ErrorNameDuplicateRequestID, ErrorNameDuplicateTransaction, ErrorNameDuplicateSenderBatchID)
+}
+
+// IsRateLimited reports whether the request was rejected because of too many requests
+func IsRateLimited(err error) bool {
+	return hasErrorName(err, ErrorNameRateLimitReached) || hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsAuthError reports whether the credentials or the access token were rejected, or the call is not permitted for them
+func IsAuthError(err error) bool {
+	if hasErrorName(err, ErrorNameAuthenticationFailure, ErrorNameNotAuthorized) ||
+		hasStatus(err, http.StatusUnauthorized, http.StatusForbidden) {
+		return true
+	}
+
+	errResp, ok := AsErrorResponse(err)
+	return ok && (errResp.OAuthError == "invalid_client" || errResp.OAuthError == "invalid_token")
+}
+
+// hasErrorName reports whether err is an ErrorResponse with one of the names
+func hasErrorName(err error, names ...string) bool {
+	errResp, ok := AsErrorResponse(err)
+	if !ok {
+		return false
+	}
+
+	for _, name := range names {
+		if errResp.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasStatus reports whether err is an ErrorResponse with one of the HTTP status codes
+func hasStatus(err error, codes ...int) bool {
+	errResp, ok := AsErrorResponse(err)
+	if !ok || errResp.Response == nil {
+		return false
+	}
+
+	for _, code := range codes {
+		if errResp.Response.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
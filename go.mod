@@ -0,0 +1,3 @@
+module github.com/logpacker/PayPal-Go-SDK
+
+go 1.13
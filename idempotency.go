@@ -0,0 +1,50 @@
+package paypalsdk
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying an idempotency key.
+// Mutating calls made with this context send it as PayPal-Request-Id header,
+// so repeating the same call with the same key (e.g. after a timeout) is processed by PayPal only once
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns an idempotency key set by WithRequestID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// NewRequestID generates a random idempotency key (UUID v4)
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// setRequestID sets PayPal-Request-Id header of req to the key from its context
+// or to a new one, the header is kept as is when already set by the caller.
+// The same header is sent on every retry of req made by Send
+func setRequestID(req *http.Request) {
+	if req.Header.Get("PayPal-Request-Id") != "" {
+		return
+	}
+
+	requestID, ok := RequestIDFromContext(req.Context())
+	if !ok {
+		requestID = NewRequestID()
+	}
+	req.Header.Set("PayPal-Request-Id", requestID)
+}
@@ -1,6 +1,7 @@
 package paypalsdk
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
@@ -8,6 +9,11 @@ import (
 // GrantNewAccessTokenFromAuthCode - Use this call to grant a new access token, using the previously obtained authorization code.
 // Endpoint: POST /v1/identity/openidconnect/tokenservice
 func (c *Client) GrantNewAccessTokenFromAuthCode(code string, redirectURI string) (*TokenResponse, error) {
+	return c.GrantNewAccessTokenFromAuthCodeContext(context.Background(), code, redirectURI)
+}
+
+// GrantNewAccessTokenFromAuthCodeContext is like GrantNewAccessTokenFromAuthCode but uses ctx for the request
+func (c *Client) GrantNewAccessTokenFromAuthCodeContext(ctx context.Context, code string, redirectURI string) (*TokenResponse, error) {
 	type request struct {
 		GrantType   string `json:"grant_type"`
 		Code        string `json:"code"`
@@ -16,7 +22,7 @@ func (c *Client) GrantNewAccessTokenFromAuthCode(code string, redirectURI string
 
 	token := &TokenResponse{}
 
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/identity/openidconnect/tokenservice"), request{GrantType: "authorization_code", Code: code, RedirectURI: redirectURI})
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/identity/openidconnect/tokenservice"), request{GrantType: "authorization_code", Code: code, RedirectURI: redirectURI})
 	if err != nil {
 		return token, err
 	}
@@ -32,6 +38,11 @@ func (c *Client) GrantNewAccessTokenFromAuthCode(code string, redirectURI string
 // GrantNewAccessTokenFromRefreshToken - Use this call to grant a new access token, using a refresh token.
 // Endpoint: POST /v1/identity/openidconnect/tokenservice
 func (c *Client) GrantNewAccessTokenFromRefreshToken(refreshToken string) (*TokenResponse, error) {
+	return c.GrantNewAccessTokenFromRefreshTokenContext(context.Background(), refreshToken)
+}
+
+// GrantNewAccessTokenFromRefreshTokenContext is like GrantNewAccessTokenFromRefreshToken but uses ctx for the request
+func (c *Client) GrantNewAccessTokenFromRefreshTokenContext(ctx context.Context, refreshToken string) (*TokenResponse, error) {
 	type request struct {
 		GrantType    string `json:"grant_type"`
 		RefreshToken string `json:"refresh_token"`
@@ -39,7 +50,7 @@ func (c *Client) GrantNewAccessTokenFromRefreshToken(refreshToken string) (*Toke
 
 	token := &TokenResponse{}
 
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/identity/openidconnect/tokenservice"), request{GrantType: "refresh_token", RefreshToken: refreshToken})
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/identity/openidconnect/tokenservice"), request{GrantType: "refresh_token", RefreshToken: refreshToken})
 	if err != nil {
 		return token, err
 	}
@@ -56,9 +67,14 @@ func (c *Client) GrantNewAccessTokenFromRefreshToken(refreshToken string) (*Toke
 // Endpoint: GET /v1/identity/openidconnect/userinfo/?schema=<Schema>
 // Pass the schema that is used to return as per openidconnect protocol. The only supported schema value is openid.
 func (c *Client) GetUserInfo(schema string) (*UserInfo, error) {
+	return c.GetUserInfoContext(context.Background(), schema)
+}
+
+// GetUserInfoContext is like GetUserInfo but uses ctx for the request
+func (c *Client) GetUserInfoContext(ctx context.Context, schema string) (*UserInfo, error) {
 	u := UserInfo{}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s%s", c.APIBase, "/v1/identity/openidconnect/userinfo/?schema=", schema), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s%s", c.APIBase, "/v1/identity/openidconnect/userinfo/?schema=", schema), nil)
 	if err != nil {
 		return &u, err
 	}
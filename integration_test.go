@@ -184,7 +184,7 @@ func TestGetPayments(t *testing.T) {
 	c, _ := NewClient(testClientID, testSecret, APIBaseSandBox)
 	c.GetAccessToken()
 
-	_, err := c.GetPayments()
+	_, err := c.GetPayments(nil)
 
 	if err != nil {
 		t.Errorf("Nil error expected")
@@ -0,0 +1,106 @@
+package paypalsdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Possible values for `rel` in Link
+//
+// https://developer.paypal.com/docs/api/overview/#hateoas-links
+const (
+	LinkRelSelf        string = "self"
+	LinkRelApprovalURL string = "approval_url"
+	LinkRelExecute     string = "execute"
+	LinkRelRefund      string = "refund"
+	LinkRelNext        string = "next"
+	LinkRelUpdate      string = "update"
+	LinkRelDelete      string = "delete"
+)
+
+// Links are the HATEOAS links of a resource
+type Links []Link
+
+// Find returns the link with the given rel
+func (l Links) Find(rel string) (Link, bool) {
+	for _, link := range l {
+		if link.Rel == rel {
+			return link, true
+		}
+	}
+
+	return Link{}, false
+}
+
+// Href returns the URL of the link with the given rel, empty if there is none
+func (l Links) Href(rel string) string {
+	link, _ := l.Find(rel)
+	return link.Href
+}
+
+// ApprovalURL returns the URL the payer must be redirected to for approval
+func (l Links) ApprovalURL() string {
+	return l.Href(LinkRelApprovalURL)
+}
+
+// Self returns the link to the resource itself, e.g. to refresh it with Client.Follow
+func (l Links) Self() (Link, bool) {
+	return l.Find(LinkRelSelf)
+}
+
+// Follow sends a GET request to the URL of link and decodes the response into out.
+// Links with another method (e.g. the POST refund link of a sale) are refused, since following them
+// changes the resource, use FollowWithBody with an explicit payload for those.
+// The link must point to the API base of c, so the access token is never sent anywhere else
+func (c *Client) Follow(link Link, out interface{}) error {
+	return c.FollowContext(context.Background(), link, out)
+}
+
+// FollowContext is like Follow but uses ctx for the request
+func (c *Client) FollowContext(ctx context.Context, link Link, out interface{}) error {
+	if method := strings.ToUpper(link.Method); method != "" && method != http.MethodGet {
+		return fmt.Errorf("paypalsdk: link %q is %s, follow it with a payload", link.Rel, method)
+	}
+
+	return c.follow(ctx, http.MethodGet, link.Href, nil, out)
+}
+
+// FollowWithBody sends payload to the URL of link with its method and decodes the response into out,
+// e.g. an empty RefundRequest to the refund link of a sale refunds it in full.
+// POST links are sent with a PayPal-Request-Id, like the other mutating calls
+func (c *Client) FollowWithBody(link Link, payload interface{}, out interface{}) error {
+	return c.FollowWithBodyContext(context.Background(), link, payload, out)
+}
+
+// FollowWithBodyContext is like FollowWithBody but uses ctx for the request
+func (c *Client) FollowWithBodyContext(ctx context.Context, link Link, payload interface{}, out interface{}) error {
+	method := strings.ToUpper(link.Method)
+	if method == "" || method == http.MethodGet {
+		return fmt.Errorf("paypalsdk: link %q is GET and takes no payload", link.Rel)
+	}
+	if payload == nil {
+		return fmt.Errorf("paypalsdk: link %q is %s and requires a payload", link.Rel, method)
+	}
+
+	return c.follow(ctx, method, link.Href, payload, out)
+}
+
+// follow sends payload to href, which must point to the API base of c
+func (c *Client) follow(ctx context.Context, method string, href string, payload interface{}, out interface{}) error {
+	href, err := c.resolveLink(href)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.NewRequestContext(ctx, method, href, payload)
+	if err != nil {
+		return err
+	}
+
+	if method == http.MethodPost {
+		setRequestID(req)
+	}
+	return c.SendWithAuth(req, out)
+}
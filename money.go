@@ -0,0 +1,246 @@
+package paypalsdk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidMoney is returned when an amount can't be parsed, e.g. it has more decimals than its currency allows
+	ErrInvalidMoney = errors.New("paypalsdk: invalid money amount")
+	// ErrCurrencyMismatch is returned by operations on amounts of different currencies
+	ErrCurrencyMismatch = errors.New("paypalsdk: currency mismatch")
+	// ErrMoneyOverflow is returned when the result of an operation doesn't fit in int64 minor units
+	ErrMoneyOverflow = errors.New("paypalsdk: money amount overflow")
+)
+
+// currencyExponents are the ISO 4217 minor unit exponents which differ from 2
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// paypalCurrencyExponents are the currencies PayPal accepts without decimals although ISO 4217 gives them 2
+//
+// https://developer.paypal.com/docs/reference/currency-codes/
+var paypalCurrencyExponents = map[string]int{
+	"HUF": 0, "TWD": 0,
+}
+
+// CurrencyExponent returns the number of decimals PayPal accepts for the currency: the ISO 4217 ones
+// except for HUF and TWD, which PayPal supports in whole units only. It's 2 for unknown currencies
+func CurrencyExponent(currency string) int {
+	currency = strings.ToUpper(currency)
+	if exp, ok := paypalCurrencyExponents[currency]; ok {
+		return exp
+	}
+	if exp, ok := currencyExponents[currency]; ok {
+		return exp
+	}
+
+	return 2
+}
+
+// Money is an amount of a currency stored as an integer number of minor units (e.g. cents),
+// so it's free of rounding errors and always formatted with the decimals PayPal expects
+type Money struct {
+	units    int64
+	currency string
+}
+
+// NewMoney returns the amount of minor units of currency, e.g. NewMoney(1050, "USD") is 10.50 USD
+func NewMoney(units int64, currency string) Money {
+	return Money{units: units, currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parses a decimal string like "10.5" or "-3" into Money.
+// Values with more decimals than the currency has are rejected instead of rounded
+func ParseMoney(value string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if len(currency) != 3 {
+		return Money{}, fmt.Errorf("%w: currency %q", ErrInvalidMoney, currency)
+	}
+
+	s := value
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+		if frac == "" {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+		}
+	}
+
+	exp := CurrencyExponent(currency)
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimals for %s", ErrInvalidMoney, value, exp, currency)
+	}
+
+	units, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyOverflow, value)
+	}
+	if negative {
+		units = -units
+	}
+
+	return Money{units: units, currency: currency}, nil
+}
+
+// Units returns the amount in minor units
+func (m Money) Units() int64 {
+	return m.units
+}
+
+// Currency returns the ISO 4217 code of the currency
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.units < 0
+}
+
+// String formats the amount the way PayPal expects it, e.g. "10.50" for USD and "1050" for JPY
+func (m Money) String() string {
+	exp := CurrencyExponent(m.currency)
+
+	sign := ""
+	units := strconv.FormatUint(uint64(m.units), 10)
+	if m.units < 0 {
+		sign = "-"
+		units = strconv.FormatUint(uint64(-(m.units+1))+1, 10)
+	}
+	if exp == 0 {
+		return sign + units
+	}
+	if len(units) <= exp {
+		units = strings.Repeat("0", exp-len(units)+1) + units
+	}
+
+	return sign + units[:len(units)-exp] + "." + units[len(units)-exp:]
+}
+
+// Add returns m + o, both must have the same currency
+func (m Money) Add(o Money) (Money, error) {
+	if m.currency != o.currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	if (o.units > 0 && m.units > math.MaxInt64-o.units) || (o.units < 0 && m.units < math.MinInt64-o.units) {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return Money{units: m.units + o.units, currency: m.currency}, nil
+}
+
+// Sub returns m - o, both must have the same currency
+func (m Money) Sub(o Money) (Money, error) {
+	if o.units == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return m.Add(Money{units: -o.units, currency: o.currency})
+}
+
+// Mul returns m multiplied by n, e.g. the price of an item times its quantity
+func (m Money) Mul(n int64) (Money, error) {
+	if m.units == 0 || n == 0 {
+		return Money{currency: m.currency}, nil
+	}
+
+	units := m.units * n
+	if units/n != m.units || (m.units == -1 && n == math.MinInt64) || (n == -1 && m.units == math.MinInt64) {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return Money{units: units, currency: m.currency}, nil
+}
+
+// Allocate splits m into parts proportional to ratios without losing a minor unit:
+// the remainder is spread one unit at a time over the first parts.
+// E.g. 10.00 USD allocated by 1, 1, 1 is 3.34, 3.33 and 3.33
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	var total int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, fmt.Errorf("paypalsdk: negative allocation ratio %d", r)
+		}
+		total += int64(r)
+	}
+	if total == 0 {
+		return nil, errors.New("paypalsdk: allocation ratios must have a positive sum")
+	}
+
+	parts := make([]Money, len(ratios))
+	remainder := m.units
+	for i, r := range ratios {
+		share, err := m.Mul(int64(r))
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = Money{units: share.units / total, currency: m.currency}
+		remainder -= parts[i].units
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i++ {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].units += step
+		remainder -= step
+	}
+
+	return parts, nil
+}
+
+// Amount converts m to an Amount
+func (m Money) Amount() Amount {
+	return Amount{Currency: m.currency, Total: m.String()}
+}
+
+// AmountPayout converts m to an AmountPayout
+func (m Money) AmountPayout() AmountPayout {
+	return AmountPayout{Currency: m.currency, Value: m.String()}
+}
+
+// Money parses the total of a into Money
+func (a Amount) Money() (Money, error) {
+	return ParseMoney(a.Total, a.Currency)
+}
+
+// Money parses the value of a into Money
+func (a AmountPayout) Money() (Money, error) {
+	return ParseMoney(a.Value, a.Currency)
+}
+
+// isDigits reports whether s consists of ASCII digits only
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
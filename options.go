@@ -0,0 +1,54 @@
+package paypalsdk
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClientOption configures a Client created by NewClient
+type ClientOption func(*Client)
+
+// WithHTTPClient makes the client send requests with hc, e.g. to use an instrumented transport
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		if hc != nil {
+			c.client = hc
+		}
+	}
+}
+
+// WithTimeout sets the time limit for every request made by the client.
+// The http.Client given to WithHTTPClient is copied, not modified
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithLocale sets the Accept-Language header of every request, "en_US" by default
+func WithLocale(locale string) ClientOption {
+	return func(c *Client) {
+		c.locale = locale
+	}
+}
+
+// WithPartnerAttributionID sets the PayPal-Partner-Attribution-Id header (BN code) of every request
+func WithPartnerAttributionID(id string) ClientOption {
+	return func(c *Client) {
+		c.partnerAttributionID = id
+	}
+}
+
+// WithBaseURL overrides the APIBase passed to NewClient
+func WithBaseURL(APIBase string) ClientOption {
+	return func(c *Client) {
+		c.APIBase = APIBase
+	}
+}
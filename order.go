@@ -1,13 +1,21 @@
 package paypalsdk
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // GetOrder retrieves order by ID
 // Endpoint: GET /v1/payments/orders/ID
 func (c *Client) GetOrder(orderID string) (*Order, error) {
+	return c.GetOrderContext(context.Background(), orderID)
+}
+
+// GetOrderContext is like GetOrder but uses ctx for the request
+func (c *Client) GetOrderContext(ctx context.Context, orderID string) (*Order, error) {
 	order := &Order{}
 
-	req, err := c.NewRequest("GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID), nil)
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID), nil)
 	if err != nil {
 		return order, err
 	}
@@ -23,17 +31,23 @@ func (c *Client) GetOrder(orderID string) (*Order, error) {
 // AuthorizeOrder - Use this call to authorize an order.
 // Endpoint: POST /v1/payments/orders/ID/authorize
 func (c *Client) AuthorizeOrder(orderID string, amount *Amount) (*Authorization, error) {
+	return c.AuthorizeOrderContext(context.Background(), orderID, amount)
+}
+
+// AuthorizeOrderContext is like AuthorizeOrder but uses ctx for the request
+func (c *Client) AuthorizeOrderContext(ctx context.Context, orderID string, amount *Amount) (*Authorization, error) {
 	type authRequest struct {
 		Amount *Amount `json:"amount"`
 	}
 
 	auth := &Authorization{}
 
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID+"/authorize"), authRequest{Amount: amount})
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID+"/authorize"), authRequest{Amount: amount})
 	if err != nil {
 		return auth, err
 	}
 
+	setRequestID(req)
 	err = c.SendWithAuth(req, auth)
 	if err != nil {
 		return auth, err
@@ -45,6 +59,11 @@ func (c *Client) AuthorizeOrder(orderID string, amount *Amount) (*Authorization,
 // CaptureOrder - Use this call to capture a payment on an order. To use this call, an original payment call must specify an intent of order.
 // Endpoint: POST /v1/payments/orders/ID/capture
 func (c *Client) CaptureOrder(orderID string, amount *Amount, isFinalCapture bool, currency *Currency) (*Capture, error) {
+	return c.CaptureOrderContext(context.Background(), orderID, amount, isFinalCapture, currency)
+}
+
+// CaptureOrderContext is like CaptureOrder but uses ctx for the request
+func (c *Client) CaptureOrderContext(ctx context.Context, orderID string, amount *Amount, isFinalCapture bool, currency *Currency) (*Capture, error) {
 	type captureRequest struct {
 		Amount         *Amount   `json:"amount"`
 		IsFinalCapture bool      `json:"is_final_capture"`
@@ -53,11 +72,12 @@ func (c *Client) CaptureOrder(orderID string, amount *Amount, isFinalCapture boo
 
 	capture := &Capture{}
 
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID+"/capture"), captureRequest{Amount: amount, IsFinalCapture: isFinalCapture, Currency: currency})
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID+"/capture"), captureRequest{Amount: amount, IsFinalCapture: isFinalCapture, Currency: currency})
 	if err != nil {
 		return capture, err
 	}
 
+	setRequestID(req)
 	err = c.SendWithAuth(req, capture)
 	if err != nil {
 		return capture, err
@@ -70,12 +90,18 @@ func (c *Client) CaptureOrder(orderID string, amount *Amount, isFinalCapture boo
 // Note: An order cannot be voided if payment has already been partially or fully captured.
 // Endpoint: POST /v1/payments/orders/ID/do-void
 func (c *Client) VoidOrder(orderID string) (*Order, error) {
+	return c.VoidOrderContext(context.Background(), orderID)
+}
+
+// VoidOrderContext is like VoidOrder but uses ctx for the request
+func (c *Client) VoidOrderContext(ctx context.Context, orderID string) (*Order, error) {
 	order := &Order{}
 
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID+"/do-void"), nil)
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/orders/"+orderID+"/do-void"), nil)
 	if err != nil {
 		return order, err
 	}
+	setRequestID(req)
 
 	err = c.SendWithAuth(req, order)
 	if err != nil {
@@ -0,0 +1,242 @@
+package paypalsdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// pageIterator walks the pages of a list endpoint. fetch loads the page at pageURL
+// and returns the number of items in it and the URL of the next page, empty for the last page.
+// The items of a page are still iterated when fetch fails to find the next page, the error is reported after them
+type pageIterator struct {
+	fetch    func(ctx context.Context, pageURL string) (n int, next string, err error)
+	pageURL  string
+	n, i     int
+	fetchErr error
+	err      error
+}
+
+// next moves to the next item, fetching the next page when the current one is exhausted
+func (it *pageIterator) next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.i++
+	for it.i >= it.n {
+		if it.pageURL == "" {
+			it.err = it.fetchErr
+			return false
+		}
+
+		n, next, err := it.fetch(ctx, it.pageURL)
+		if err != nil || next == it.pageURL {
+			next = ""
+		}
+		it.n, it.i, it.pageURL, it.fetchErr = n, 0, next, err
+	}
+
+	return true
+}
+
+// nextPageURL returns the URL of the "next" link, resolved against the API base of c.
+// Links to other hosts are rejected, so the access token is never sent outside of the API
+func (c *Client) nextPageURL(links Links) (string, error) {
+	if l, ok := links.Find(LinkRelNext); ok {
+		return c.resolveLink(l.Href)
+	}
+
+	return "", nil
+}
+
+// resolveLink resolves href against the API base of c and checks that it points to the API
+func (c *Client) resolveLink(href string) (string, error) {
+	base, err := url.Parse(c.APIBase)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != base.Scheme || u.Host != base.Host {
+		return "", fmt.Errorf("paypalsdk: link %q doesn't point to %s", href, c.APIBase)
+	}
+
+	return u.String(), nil
+}
+
+// withQuery returns rawURL with the query parameter key set to value
+func withQuery(rawURL string, key string, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// PaymentIterator iterates over all payments matching a filter, fetching the pages on demand
+type PaymentIterator struct {
+	pageIterator
+	page ListPaymentsResp
+}
+
+// IteratePayments returns an iterator over the payments, following next_id or the "next" link from page to page
+//
+//	it := c.IteratePayments(&paypalsdk.PaymentsFilter{Count: 20})
+//	for it.Next(ctx) {
+//	    p := it.Value()
+//	}
+//	err := it.Err()
+func (c *Client) IteratePayments(filter *PaymentsFilter) *PaymentIterator {
+	path := "/v1/payments/payment"
+	if q := filter.query(); len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	it := &PaymentIterator{}
+	it.pageURL = fmt.Sprintf("%s%s", c.APIBase, path)
+	it.fetch = func(ctx context.Context, pageURL string) (int, string, error) {
+		it.page = ListPaymentsResp{}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+		if err != nil {
+			return 0, "", err
+		}
+		if err = c.SendWithAuth(req, &it.page); err != nil {
+			return 0, "", err
+		}
+
+		next, err := c.nextPageURL(it.page.Links)
+		if next == "" && err == nil && it.page.NextID != "" {
+			next, err = withQuery(pageURL, "start_id", it.page.NextID)
+		}
+
+		return len(it.page.Payments), next, err
+	}
+
+	return it
+}
+
+// Next advances the iterator to the next payment. It returns false when there are no more payments or an error occurred
+func (it *PaymentIterator) Next(ctx context.Context) bool {
+	return it.next(ctx)
+}
+
+// Value returns the current payment
+func (it *PaymentIterator) Value() *Payment {
+	return &it.page.Payments[it.i]
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *PaymentIterator) Err() error {
+	return it.err
+}
+
+// CreditCardIterator iterates over all vaulted credit cards, fetching the pages on demand
+type CreditCardIterator struct {
+	pageIterator
+	page CreditCards
+}
+
+// IterateCreditCards returns an iterator over the vaulted credit cards starting at the page of ccf,
+// following the "next" link or the page number up to total_pages
+func (c *Client) IterateCreditCards(ccf *CreditCardsFilter) *CreditCardIterator {
+	page := 1
+	if ccf != nil && ccf.Page > 0 {
+		page = ccf.Page
+	}
+	pageSize := 10
+	if ccf != nil && ccf.PageSize > 0 {
+		pageSize = ccf.PageSize
+	}
+
+	it := &CreditCardIterator{}
+	it.pageURL = fmt.Sprintf("%s/v1/vault/credit-cards?page=%d&page_size=%d", c.APIBase, page, pageSize)
+	it.fetch = func(ctx context.Context, pageURL string) (int, string, error) {
+		it.page = CreditCards{}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+		if err != nil {
+			return 0, "", err
+		}
+		if err = c.SendWithAuth(req, &it.page); err != nil {
+			return 0, "", err
+		}
+
+		next, err := c.nextPageURL(it.page.Links)
+		if next == "" && err == nil && page < it.page.TotalPages {
+			next, err = withQuery(pageURL, "page", strconv.Itoa(page+1))
+		}
+		page++
+
+		return len(it.page.Items), next, err
+	}
+
+	return it
+}
+
+// Next advances the iterator to the next credit card. It returns false when there are no more cards or an error occurred
+func (it *CreditCardIterator) Next(ctx context.Context) bool {
+	return it.next(ctx)
+}
+
+// Value returns the current credit card
+func (it *CreditCardIterator) Value() *CreditCard {
+	return &it.page.Items[it.i]
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *CreditCardIterator) Err() error {
+	return it.err
+}
+
+// WebProfileIterator iterates over all web experience profiles
+type WebProfileIterator struct {
+	pageIterator
+	page []WebProfile
+}
+
+// IterateWebProfiles returns an iterator over the web experience profiles.
+// The API returns all of them at once, so there is a single page
+func (c *Client) IterateWebProfiles() *WebProfileIterator {
+	it := &WebProfileIterator{}
+	it.pageURL = fmt.Sprintf("%s%s", c.APIBase, "/v1/payment-experience/web-profiles")
+	it.fetch = func(ctx context.Context, pageURL string) (int, string, error) {
+		it.page = nil
+
+		req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+		if err != nil {
+			return 0, "", err
+		}
+		if err = c.SendWithAuth(req, &it.page); err != nil {
+			return 0, "", err
+		}
+
+		return len(it.page), "", nil
+	}
+
+	return it
+}
+
+// Next advances the iterator to the next web profile. It returns false when there are no more profiles or an error occurred
+func (it *WebProfileIterator) Next(ctx context.Context) bool {
+	return it.next(ctx)
+}
+
+// Value returns the current web profile
+func (it *WebProfileIterator) Value() *WebProfile {
+	return &it.page[it.i]
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *WebProfileIterator) Err() error {
+	return it.err
+}
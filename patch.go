@@ -0,0 +1,89 @@
+package paypalsdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Possible values for `op` in PatchOperation
+//
+// https://developer.paypal.com/docs/api/payments/#definition-patch
+const (
+	PatchOpAdd     string = "add"
+	PatchOpReplace string = "replace"
+	PatchOpRemove  string = "remove"
+)
+
+// PatchOperation is an operation of a JSON Patch (RFC 6902) request
+type PatchOperation struct {
+	Operation string      `json:"op"`
+	Path      string      `json:"path"`
+	Value     interface{} `json:"value,omitempty"`
+}
+
+// Patch is a list of JSON Patch operations, built with Add, Replace and Remove:
+//
+//	patch := paypalsdk.Patch{}.
+//	    Replace(paypalsdk.TransactionAmountPath(0), amount).
+//	    Add(paypalsdk.TransactionInvoiceNumberPath(0), "INV-1001")
+type Patch []PatchOperation
+
+// Add returns p with an operation adding value at path
+func (p Patch) Add(path string, value interface{}) Patch {
+	return append(p, PatchOperation{Operation: PatchOpAdd, Path: path, Value: value})
+}
+
+// Replace returns p with an operation replacing the value at path
+func (p Patch) Replace(path string, value interface{}) Patch {
+	return append(p, PatchOperation{Operation: PatchOpReplace, Path: path, Value: value})
+}
+
+// Remove returns p with an operation removing the value at path
+func (p Patch) Remove(path string) Patch {
+	return append(p, PatchOperation{Operation: PatchOpRemove, Path: path})
+}
+
+// PatchPath builds a JSON Pointer (RFC 6901) from field names and array indexes,
+// e.g. PatchPath("transactions", 0, "amount") is "/transactions/0/amount"
+func PatchPath(tokens ...interface{}) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(escaper.Replace(fmt.Sprint(t)))
+	}
+
+	return b.String()
+}
+
+// TransactionAmountPath is the path of the amount of a payment transaction
+func TransactionAmountPath(transaction int) string {
+	return PatchPath("transactions", transaction, "amount")
+}
+
+// TransactionShippingAddressPath is the path of the shipping address of a payment transaction
+func TransactionShippingAddressPath(transaction int) string {
+	return PatchPath("transactions", transaction, "item_list", "shipping_address")
+}
+
+// TransactionInvoiceNumberPath is the path of the invoice number of a payment transaction
+func TransactionInvoiceNumberPath(transaction int) string {
+	return PatchPath("transactions", transaction, "invoice_number")
+}
+
+// TransactionCustomPath is the path of the custom field of a payment transaction
+func TransactionCustomPath(transaction int) string {
+	return PatchPath("transactions", transaction, "custom")
+}
+
+// sendPatch sends the JSON patch operations to url and decodes the response into v
+func (c *Client) sendPatch(ctx context.Context, url string, operations interface{}, v interface{}) error {
+	req, err := c.NewRequestContext(ctx, "PATCH", url, operations)
+	if err != nil {
+		return err
+	}
+
+	return c.SendWithAuth(req, v)
+}
@@ -1,40 +1,85 @@
 package paypalsdk
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 // ListPaymentsResp slice of payments
 type ListPaymentsResp struct {
 	Payments []Payment `json:"payments"`
+	Count    int       `json:"count"`
+	NextID   string    `json:"next_id,omitempty"`
+	Links    Links     `json:"links,omitempty"`
 }
 
 // CreatePaymentResp contains Payment Info and Links slice
 type CreatePaymentResp struct {
 	*Payment
-	Links []Link `json:"links"`
+	Links Links `json:"links"`
+}
+
+// PaymentOption adds optional fields to the payment created by CreateDirectPaypalPayment
+type PaymentOption func(*Payment)
+
+// WithPaymentItems sets the item list of the payment transaction
+func WithPaymentItems(items ...Item) PaymentOption {
+	return func(p *Payment) {
+		p.Transactions[0].ItemList = &ItemList{Items: items}
+	}
+}
+
+// WithExperienceProfileID sets the web experience profile used for the payment approval pages
+func WithExperienceProfileID(profileID string) PaymentOption {
+	return func(p *Payment) {
+		p.ExperienceProfileID = profileID
+	}
+}
+
+// WithInvoiceNumber sets the invoice number of the payment transaction
+func WithInvoiceNumber(invoiceNumber string) PaymentOption {
+	return func(p *Payment) {
+		p.Transactions[0].InvoiceNumber = invoiceNumber
+	}
 }
 
 // CreateDirectPaypalPayment sends request to create a payment with payment_method=paypal
 // CreatePayment is more common function for any kind of payment
 // Endpoint: POST /v1/payments/payment
-func (c *Client) CreateDirectPaypalPayment(amount Amount, redirectURI string, cancelURI string, description string) (*PaymentResponse, error) {
-	buf := bytes.NewBuffer([]byte("{\"intent\":\"sale\",\"payer\":{\"payment_method\":\"paypal\"}," +
-		"\"transactions\":[{\"amount\":{\"total\":\"" + amount.Total +
-		"\",\"currency\":\"" + amount.Currency + "\"},\"description\":\"" + description + "\"}],\"redirect_urls\":{\"return_url\":\"" +
-		redirectURI + "\",\"cancel_url\":\"" + cancelURI + "\"}}"))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment"), buf)
+func (c *Client) CreateDirectPaypalPayment(amount Amount, redirectURI string, cancelURI string, description string, opts ...PaymentOption) (*PaymentResponse, error) {
+	return c.CreateDirectPaypalPaymentContext(context.Background(), amount, redirectURI, cancelURI, description, opts...)
+}
+
+// CreateDirectPaypalPaymentContext is like CreateDirectPaypalPayment but uses ctx for the request
+func (c *Client) CreateDirectPaypalPaymentContext(ctx context.Context, amount Amount, redirectURI string, cancelURI string, description string, opts ...PaymentOption) (*PaymentResponse, error) {
+	payment := Payment{
+		Intent: "sale",
+		Payer:  &Payer{PaymentMethod: "paypal"},
+		Transactions: []Transaction{{
+			Amount:      &amount,
+			Description: description,
+		}},
+		RedirectURLs: &RedirectURLs{
+			ReturnURL: redirectURI,
+			CancelURL: cancelURI,
+		},
+	}
+	for _, opt := range opts {
+		opt(&payment)
+	}
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment"), payment)
 	if err != nil {
 		return &PaymentResponse{}, err
 	}
 
-	req.SetBasicAuth(c.ClientID, c.Secret)
-	req.Header.Set("Authorization", "Bearer "+c.Token.Token)
-
 	p := PaymentResponse{}
+	setRequestID(req)
 	err = c.SendWithAuth(req, &p)
 	if err != nil {
 		return &p, err
@@ -51,13 +96,19 @@ func (c *Client) CreateDirectPaypalPayment(amount Amount, redirectURI string, ca
 // Depending on the payment_method and the funding_instrument, you can use the payment resource for direct credit card payments, stored credit card payments, or PayPal account payments.
 // Endpoint: POST /v1/payments/payment
 func (c *Client) CreatePayment(p Payment) (*CreatePaymentResp, error) {
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment"), p)
+	return c.CreatePaymentContext(context.Background(), p)
+}
+
+// CreatePaymentContext is like CreatePayment but uses ctx for the request
+func (c *Client) CreatePaymentContext(ctx context.Context, p Payment) (*CreatePaymentResp, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment"), p)
 	if err != nil {
 		return &CreatePaymentResp{}, err
 	}
 
 	response := &CreatePaymentResp{}
 
+	setRequestID(req)
 	err = c.SendWithAuth(req, response)
 	if err != nil {
 		return response, err
@@ -68,17 +119,24 @@ func (c *Client) CreatePayment(p Payment) (*CreatePaymentResp, error) {
 
 // ExecuteApprovedPayment - Use this call to execute (complete) a PayPal payment that has been approved by the payer. You can optionally update transaction information when executing the payment by passing in one or more transactions.
 // Endpoint: POST /v1/payments/payment/paymentID/execute
-func (c *Client) ExecuteApprovedPayment(paymentID string, payerID string) (*ExecuteResponse, error) {
-	buf := bytes.NewBuffer([]byte("{\"payer_id\":\"" + payerID + "\"}"))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment/"+paymentID+"/execute"), buf)
+func (c *Client) ExecuteApprovedPayment(paymentID string, payerID string, transactions ...Transaction) (*ExecuteResponse, error) {
+	return c.ExecuteApprovedPaymentContext(context.Background(), paymentID, payerID, transactions...)
+}
+
+// ExecuteApprovedPaymentContext is like ExecuteApprovedPayment but uses ctx for the request
+func (c *Client) ExecuteApprovedPaymentContext(ctx context.Context, paymentID string, payerID string, transactions ...Transaction) (*ExecuteResponse, error) {
+	type request struct {
+		PayerID      string        `json:"payer_id"`
+		Transactions []Transaction `json:"transactions,omitempty"`
+	}
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment/"+paymentID+"/execute"), request{PayerID: payerID, Transactions: transactions})
 	if err != nil {
 		return &ExecuteResponse{}, err
 	}
 
-	req.SetBasicAuth(c.ClientID, c.Secret)
-	req.Header.Set("Authorization", "Bearer "+c.Token.Token)
-
 	e := ExecuteResponse{}
+	setRequestID(req)
 	err = c.SendWithAuth(req, &e)
 	if err != nil {
 		return &e, err
@@ -94,9 +152,14 @@ func (c *Client) ExecuteApprovedPayment(paymentID string, payerID string) (*Exec
 // GetPayment gets a payment from PayPal
 // Endpoint: GET /v1/payments/payment/ID
 func (c *Client) GetPayment(paymentID string) (*Payment, error) {
+	return c.GetPaymentContext(context.Background(), paymentID)
+}
+
+// GetPaymentContext is like GetPayment but uses ctx for the request
+func (c *Client) GetPaymentContext(ctx context.Context, paymentID string) (*Payment, error) {
 	p := Payment{}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment/"+paymentID), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment/"+paymentID), nil)
 	if err != nil {
 		return &p, err
 	}
@@ -113,20 +176,80 @@ func (c *Client) GetPayment(paymentID string) (*Payment, error) {
 	return &p, nil
 }
 
-// GetPayments retrieve payments resources from Paypal
+// UpdatePayment applies JSON patch operations to a created payment before it's executed,
+// e.g. to change the amount, shipping address, invoice number or custom field of a transaction
+// Endpoint: PATCH /v1/payments/payment/ID
+func (c *Client) UpdatePayment(paymentID string, patch Patch) (*Payment, error) {
+	return c.UpdatePaymentContext(context.Background(), paymentID, patch)
+}
+
+// UpdatePaymentContext is like UpdatePayment but uses ctx for the request
+func (c *Client) UpdatePaymentContext(ctx context.Context, paymentID string, patch Patch) (*Payment, error) {
+	p := Payment{}
+
+	err := c.sendPatch(ctx, fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment/"+paymentID), patch, &p)
+	if err != nil {
+		return &p, err
+	}
+
+	return &p, nil
+}
+
+// GetPayments retrieve payments resources from Paypal, filtered by pf
+// The response contains count and next_id of the next page, see IteratePayments to list all payments
 // Endpoint: GET /v1/payments/payment/
-func (c *Client) GetPayments() ([]Payment, error) {
-	var p ListPaymentsResp
+func (c *Client) GetPayments(pf *PaymentsFilter) (*ListPaymentsResp, error) {
+	return c.GetPaymentsContext(context.Background(), pf)
+}
+
+// GetPaymentsContext is like GetPayments but uses ctx for the request
+func (c *Client) GetPaymentsContext(ctx context.Context, pf *PaymentsFilter) (*ListPaymentsResp, error) {
+	p := ListPaymentsResp{}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payment/"), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/payments/payment/?%s", c.APIBase, pf.query().Encode()), nil)
 	if err != nil {
-		return p.Payments, err
+		return &p, err
 	}
 
 	err = c.SendWithAuth(req, &p)
 	if err != nil {
-		return p.Payments, err
+		return &p, err
+	}
+
+	return &p, nil
+}
+
+// query returns the query parameters of the filter, nil filter has none
+func (f *PaymentsFilter) query() url.Values {
+	q := url.Values{}
+	if f == nil {
+		return q
+	}
+
+	if f.Count > 0 {
+		q.Set("count", strconv.Itoa(f.Count))
+	}
+	if f.StartID != "" {
+		q.Set("start_id", f.StartID)
+	}
+	if f.StartIndex > 0 {
+		q.Set("start_index", strconv.Itoa(f.StartIndex))
+	}
+	if f.StartTime != nil {
+		q.Set("start_time", f.StartTime.UTC().Format(time.RFC3339))
+	}
+	if f.EndTime != nil {
+		q.Set("end_time", f.EndTime.UTC().Format(time.RFC3339))
+	}
+	if f.SortBy != "" {
+		q.Set("sort_by", f.SortBy)
+	}
+	if f.SortOrder != "" {
+		q.Set("sort_order", f.SortOrder)
+	}
+	if f.PayeeID != "" {
+		q.Set("payee_id", f.PayeeID)
 	}
 
-	return p.Payments, nil
+	return q
 }
@@ -1,18 +1,88 @@
 package paypalsdk
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
 
 // CreateSinglePayout submits a payout with a synchronous API call, which immediately returns the results of a PayPal payment.
 // For email payout set RecipientType: "EMAIL" and receiver email into Receiver
 // Endpoint: POST /v1/payments/payouts?sync_mode=true
 func (c *Client) CreateSinglePayout(p Payout) (*PayoutResponse, error) {
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payouts?sync_mode=true"), p)
+	return c.CreateSinglePayoutContext(context.Background(), p)
+}
+
+// CreateSinglePayoutContext is like CreateSinglePayout but uses ctx for the request
+func (c *Client) CreateSinglePayoutContext(ctx context.Context, p Payout) (*PayoutResponse, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payouts?sync_mode=true"), p)
 	if err != nil {
 		return &PayoutResponse{}, err
 	}
 
 	response := &PayoutResponse{}
 
+	setRequestID(req)
+	err = c.SendWithAuth(req, response)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// CreateBatchPayout submits a payout to many recipients asynchronously, the result contains only the batch header.
+// Set SenderBatchHeader.SenderBatchID to a unique value, PayPal rejects batches with a used ID, which prevents double payouts.
+// Use GetPayoutBatch or PollPayoutBatch to get the outcome of every item
+// Endpoint: POST /v1/payments/payouts
+func (c *Client) CreateBatchPayout(p Payout) (*PayoutResponse, error) {
+	return c.CreateBatchPayoutContext(context.Background(), p)
+}
+
+// CreateBatchPayoutContext is like CreateBatchPayout but uses ctx for the request
+func (c *Client) CreateBatchPayoutContext(ctx context.Context, p Payout) (*PayoutResponse, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payouts"), p)
+	if err != nil {
+		return &PayoutResponse{}, err
+	}
+
+	response := &PayoutResponse{}
+
+	setRequestID(req)
+	err = c.SendWithAuth(req, response)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// GetPayoutBatch returns the batch header and one page of items of a payout batch, by default the first 1000 items
+// Endpoint: GET /v1/payments/payouts/ID
+func (c *Client) GetPayoutBatch(batchID string, pbf *PayoutBatchFilter) (*PayoutResponse, error) {
+	return c.GetPayoutBatchContext(context.Background(), batchID, pbf)
+}
+
+// GetPayoutBatchContext is like GetPayoutBatch but uses ctx for the request
+func (c *Client) GetPayoutBatchContext(ctx context.Context, batchID string, pbf *PayoutBatchFilter) (*PayoutResponse, error) {
+	q := url.Values{}
+	q.Set("total_required", "true")
+	if pbf != nil && pbf.Page > 0 {
+		q.Set("page", strconv.Itoa(pbf.Page))
+	}
+	if pbf != nil && pbf.PageSize > 0 {
+		q.Set("page_size", strconv.Itoa(pbf.PageSize))
+	}
+
+	response := &PayoutResponse{}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s/v1/payments/payouts/%s?%s", c.APIBase, batchID, q.Encode()), nil)
+	if err != nil {
+		return response, err
+	}
+
 	err = c.SendWithAuth(req, response)
 	if err != nil {
 		return response, err
@@ -20,3 +90,100 @@ func (c *Client) CreateSinglePayout(p Payout) (*PayoutResponse, error) {
 
 	return response, nil
 }
+
+// GetPayoutItem returns a payout item by ID
+// Endpoint: GET /v1/payments/payouts-item/ID
+func (c *Client) GetPayoutItem(itemID string) (*PayoutItemResponse, error) {
+	return c.GetPayoutItemContext(context.Background(), itemID)
+}
+
+// GetPayoutItemContext is like GetPayoutItem but uses ctx for the request
+func (c *Client) GetPayoutItemContext(ctx context.Context, itemID string) (*PayoutItemResponse, error) {
+	item := &PayoutItemResponse{}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payouts-item/"+itemID), nil)
+	if err != nil {
+		return item, err
+	}
+
+	err = c.SendWithAuth(req, item)
+	if err != nil {
+		return item, err
+	}
+
+	return item, nil
+}
+
+// CancelUnclaimedPayoutItem cancels a payout item in UNCLAIMED status, the amount is returned to the sender
+// Endpoint: POST /v1/payments/payouts-item/ID/cancel
+func (c *Client) CancelUnclaimedPayoutItem(itemID string) (*PayoutItemResponse, error) {
+	return c.CancelUnclaimedPayoutItemContext(context.Background(), itemID)
+}
+
+// CancelUnclaimedPayoutItemContext is like CancelUnclaimedPayoutItem but uses ctx for the request
+func (c *Client) CancelUnclaimedPayoutItemContext(ctx context.Context, itemID string) (*PayoutItemResponse, error) {
+	item := &PayoutItemResponse{}
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/payouts-item/"+itemID+"/cancel"), nil)
+	if err != nil {
+		return item, err
+	}
+	setRequestID(req)
+
+	err = c.SendWithAuth(req, item)
+	if err != nil {
+		return item, err
+	}
+
+	return item, nil
+}
+
+// defaultPayoutPollInterval is used by PollPayoutBatch for a non-positive interval
+const defaultPayoutPollInterval = 5 * time.Second
+
+// PollPayoutBatch checks the payout batch every interval until its status is SUCCESS, DENIED or CANCELED,
+// then returns the batch with items of all pages. It stops with the error of ctx when ctx is done.
+// A non-positive interval polls every 5 seconds
+func (c *Client) PollPayoutBatch(ctx context.Context, batchID string, interval time.Duration) (*PayoutResponse, error) {
+	if interval <= 0 {
+		interval = defaultPayoutPollInterval
+	}
+
+	for {
+		batch, err := c.GetPayoutBatchContext(ctx, batchID, &PayoutBatchFilter{Page: 1})
+		if err != nil {
+			return batch, err
+		}
+
+		if batch.BatchHeader != nil && isBatchStatusFinal(batch.BatchHeader.BatchStatus) {
+			for page := 2; page <= batch.TotalPage; page++ {
+				next, err := c.GetPayoutBatchContext(ctx, batchID, &PayoutBatchFilter{Page: page})
+				if err != nil {
+					return batch, err
+				}
+				batch.Items = append(batch.Items, next.Items...)
+			}
+
+			return batch, nil
+		}
+
+		if err = sleepContext(ctx, interval); err != nil {
+			return batch, err
+		}
+	}
+}
+
+// ItemsByStatus groups items of the payout by their transaction status, e.g. SUCCESS, FAILED or UNCLAIMED
+func (r *PayoutResponse) ItemsByStatus() map[string][]PayoutItemResponse {
+	items := make(map[string][]PayoutItemResponse)
+	for _, item := range r.Items {
+		items[item.TransactionStatus] = append(items[item.TransactionStatus], item)
+	}
+
+	return items
+}
+
+// isBatchStatusFinal reports whether a payout batch with this status won't change anymore
+func isBatchStatusFinal(status string) bool {
+	return status == BatchStatusSuccess || status == BatchStatusDenied || status == BatchStatusCanceled
+}
@@ -0,0 +1,134 @@
+package paypalsdk
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how Send retries failed requests.
+// Only transport errors, 429 and 5xx responses are retried, and only for requests
+// which are safe to repeat: GET, HEAD, OPTIONS, PUT, DELETE or any request carrying
+// a PayPal-Request-Id header, which makes PayPal process it at most once
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, it doubles on every next retry
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Jitter is the fraction (0..1) of the delay which is randomised to spread retries of many clients
+	Jitter float64
+}
+
+// RetryError is returned by Send when a RetryPolicy is set.
+// It carries the number of attempts made, the last error is available via Unwrap
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and an exponential backoff from 500ms up to 10s
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// SetRetryPolicy sets/changes the retry policy used by Send. nil disables retries
+func (c *Client) SetRetryPolicy(p *RetryPolicy) error {
+	c.RetryPolicy = p
+	return nil
+}
+
+// Error method implementation for RetryError struct
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+// Unwrap returns the error of the last attempt
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// shouldRetry reports whether the attempt which returned resp/err must be repeated
+func (p *RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) bool {
+	if p == nil || attempt >= p.MaxAttempts || req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+	default:
+		if req.Header.Get("PayPal-Request-Id") == "" {
+			return false
+		}
+	}
+
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns the delay before the next attempt, Retry-After of resp takes precedence
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+
+	d := p.MinBackoff << uint(attempt-1)
+	if d <= 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		j := time.Duration(p.Jitter * float64(d))
+		d = d - j + time.Duration(rand.Int63n(int64(j)*2+1))
+	}
+
+	return d
+}
+
+// parseRetryAfter parses Retry-After header given in seconds or as HTTP date
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
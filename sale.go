@@ -1,15 +1,23 @@
 package paypalsdk
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // GetSale returns a sale by ID
 // Use this call to get details about a sale transaction.
 // Note: This call returns only the sales that were created via the REST API.
 // Endpoint: GET /v1/payments/sale/ID
 func (c *Client) GetSale(saleID string) (*Sale, error) {
+	return c.GetSaleContext(context.Background(), saleID)
+}
+
+// GetSaleContext is like GetSale but uses ctx for the request
+func (c *Client) GetSaleContext(ctx context.Context, saleID string) (*Sale, error) {
 	sale := &Sale{}
 
-	req, err := c.NewRequest("GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/sale/"+saleID), nil)
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/sale/"+saleID), nil)
 	if err != nil {
 		return sale, err
 	}
@@ -26,17 +34,23 @@ func (c *Client) GetSale(saleID string) (*Sale, error) {
 // Use this call to refund a completed payment. Provide the sale_id in the URI and an empty JSON payload for a full refund. For partial refunds, you can include an amount.
 // Endpoint: POST /v1/payments/sale/ID/refund
 func (c *Client) RefundSale(saleID string, a *Amount) (*Refund, error) {
+	return c.RefundSaleContext(context.Background(), saleID, a)
+}
+
+// RefundSaleContext is like RefundSale but uses ctx for the request
+func (c *Client) RefundSaleContext(ctx context.Context, saleID string, a *Amount) (*Refund, error) {
 	type refundRequest struct {
 		Amount *Amount `json:"amount"`
 	}
 
 	refund := &Refund{}
 
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/sale/"+saleID+"/refund"), &refundRequest{Amount: a})
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/sale/"+saleID+"/refund"), &refundRequest{Amount: a})
 	if err != nil {
 		return refund, err
 	}
 
+	setRequestID(req)
 	err = c.SendWithAuth(req, refund)
 	if err != nil {
 		return refund, err
@@ -49,9 +63,14 @@ func (c *Client) RefundSale(saleID string, a *Amount) (*Refund, error) {
 // Use it to look up details of a specific refund on direct and captured payments.
 // Endpoint: GET /v1/payments/refund/ID
 func (c *Client) GetRefund(refundID string) (*Refund, error) {
+	return c.GetRefundContext(context.Background(), refundID)
+}
+
+// GetRefundContext is like GetRefund but uses ctx for the request
+func (c *Client) GetRefundContext(ctx context.Context, refundID string) (*Refund, error) {
 	refund := &Refund{}
 
-	req, err := c.NewRequest("GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/refund/"+refundID), nil)
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/payments/refund/"+refundID), nil)
 	if err != nil {
 		return refund, err
 	}
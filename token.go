@@ -0,0 +1,118 @@
+package paypalsdk
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// tokenFetchTimeout bounds a shared token refresh, which outlives the callers waiting for it
+const tokenFetchTimeout = time.Minute
+
+// tokenRefresh is an access token request shared by all callers which need a new token at the same time
+type tokenRefresh struct {
+	done  chan struct{}
+	token *TokenResponse
+	err   error
+}
+
+// detachedContext keeps the values of its parent but not its cancellation and deadline
+type detachedContext struct {
+	parent context.Context
+}
+
+// Deadline implements context.Context
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done implements context.Context
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+// Err implements context.Context
+func (detachedContext) Err() error {
+	return nil
+}
+
+// Value implements context.Context
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// Expired reports whether the token is expired or expires in less than RequestNewTokenBeforeExpiresIn seconds.
+// Tokens with unknown expiry (e.g. set by SetAccessToken) never expire
+func (t *TokenResponse) Expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Until(t.ExpiresAt) < RequestNewTokenBeforeExpiresIn*time.Second
+}
+
+// validToken returns the current token of c, fetching a new one when it is about to expire
+// or when it is still the rejected token. A client without a token fetches one on first use.
+// Only one fetch is made for all concurrent callers
+func (c *Client) validToken(ctx context.Context, rejected *TokenResponse) (*TokenResponse, error) {
+	c.tokenMu.Lock()
+	token := c.Token
+	if token != nil && token.Token != "" && token != rejected && !token.Expired() {
+		c.tokenMu.Unlock()
+		return token, nil
+	}
+
+	r := c.tokenRefresh
+	if r == nil {
+		if err := ctx.Err(); err != nil {
+			c.tokenMu.Unlock()
+			return nil, err
+		}
+
+		r = &tokenRefresh{done: make(chan struct{})}
+		c.tokenRefresh = r
+		go c.fetchToken(ctx, r, rejected)
+	}
+	c.tokenMu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.token, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchToken runs the shared refresh r. It isn't cancelled with ctx of the caller which started it,
+// so the other callers waiting for r still get the token; it gives up after tokenFetchTimeout
+func (c *Client) fetchToken(ctx context.Context, r *tokenRefresh, rejected *TokenResponse) {
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, tokenFetchTimeout)
+	defer cancel()
+
+	r.token, r.err = c.refreshToken(ctx, rejected)
+
+	c.tokenMu.Lock()
+	c.tokenRefresh = nil
+	c.tokenMu.Unlock()
+	close(r.done)
+}
+
+// refreshToken replaces c.Token with a valid token from c.TokenStore or,
+// if there is none, with a new one from the API
+func (c *Client) refreshToken(ctx context.Context, rejected *TokenResponse) (*TokenResponse, error) {
+	if c.TokenStore != nil {
+		token, err := c.TokenStore.GetToken(ctx)
+		if err == nil && token != nil && token.Token != "" && !token.Expired() &&
+			(rejected == nil || token.Token != rejected.Token) {
+			c.tokenMu.Lock()
+			c.Token = token
+			c.tokenMu.Unlock()
+
+			return token, nil
+		}
+	}
+
+	// c.Token will be updated in GetAccessToken call
+	return c.GetAccessTokenContext(ctx)
+}
+
+// isTokenRejected reports whether err is a 401 response of the API
+func isTokenRejected(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
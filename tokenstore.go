@@ -0,0 +1,110 @@
+package paypalsdk
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TokenStore keeps access tokens outside of a Client, so they can be reused
+// by other clients, processes or after restart.
+// The Client consults the store before requesting a new token from /v1/oauth2/token
+// and saves every new token into it. Errors of the store are not fatal,
+// the Client falls back to requesting a new token
+type TokenStore interface {
+	// GetToken returns the stored token, or nil if there is none
+	GetToken(ctx context.Context) (*TokenResponse, error)
+	// SetToken saves the token
+	SetToken(ctx context.Context, t *TokenResponse) error
+}
+
+// MemoryTokenStore is a TokenStore which keeps the token in memory.
+// It can be shared by several clients of the same process
+type MemoryTokenStore struct {
+	mu    sync.RWMutex
+	token *TokenResponse
+}
+
+// FileTokenStore is a TokenStore which keeps the token as JSON in a file
+type FileTokenStore struct {
+	Path string
+}
+
+// NewMemoryTokenStore returns new MemoryTokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// NewFileTokenStore returns new FileTokenStore which uses the file at path
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+// SetTokenStore sets/changes the token store of the client
+func (c *Client) SetTokenStore(s TokenStore) error {
+	c.TokenStore = s
+	return nil
+}
+
+// GetToken implements TokenStore
+func (s *MemoryTokenStore) GetToken(ctx context.Context) (*TokenResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.token, nil
+}
+
+// SetToken implements TokenStore
+func (s *MemoryTokenStore) SetToken(ctx context.Context, t *TokenResponse) error {
+	s.mu.Lock()
+	s.token = t
+	s.mu.Unlock()
+
+	return nil
+}
+
+// GetToken implements TokenStore, a missing file means there is no token
+func (s *FileTokenStore) GetToken(ctx context.Context) (*TokenResponse, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TokenResponse{}
+	if err = json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// SetToken implements TokenStore
+// The file is replaced atomically, so concurrent readers never see a partial token
+func (s *FileTokenStore) SetToken(ctx context.Context, t *TokenResponse) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.Path)
+}
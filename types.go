@@ -1,9 +1,11 @@
 package paypalsdk
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -35,6 +37,37 @@ const (
 	AddrOverrideFromCall uint = 1
 )
 
+// Possible values for `state` in Plan
+//
+// https://developer.paypal.com/docs/api/payments.billing-plans/#definition-plan
+const (
+	PlanStateCreated string = "CREATED"
+	PlanStateActive string = "ACTIVE"
+	PlanStateInactive string = "INACTIVE"
+	PlanStateDeleted string = "DELETED"
+)
+
+// Possible values for `batch_status` in BatchHeader
+//
+// https://developer.paypal.com/docs/api/payments.payouts-batch/#definition-batch_header
+const (
+	BatchStatusDenied string = "DENIED"
+	BatchStatusPending string = "PENDING"
+	BatchStatusProcessing string = "PROCESSING"
+	BatchStatusSuccess string = "SUCCESS"
+	BatchStatusCanceled string = "CANCELED"
+)
+
+// Possible values for `sort_by` and `sort_order` in PaymentsFilter
+//
+// https://developer.paypal.com/docs/api/payments/#payment_list
+const (
+	PaymentsSortByCreateTime string = "create_time"
+	PaymentsSortByUpdateTime string = "update_time"
+	SortOrderAsc string = "asc"
+	SortOrderDesc string = "desc"
+)
+
 // Possible values for `landing_page_type` in FlowConfig
 //
 // https://developer.paypal.com/docs/api/payment-experience/#definition-flow_config
@@ -74,22 +107,22 @@ type (
 		/**
 		 * Details of the agreement.
 		 */
-		AgreementDetails            AgreementDetails `json:"agreement_details,omitempty"`
+		AgreementDetails            *AgreementDetails `json:"agreement_details,omitempty"`
 
 		/**
 		 * Details of the buyer who is enrolling in this agreement. This information is gathered from execution of the approval URL.
 		 */
-		Payer                       Payer `json:"payer,omitempty"`
+		Payer                       *Payer `json:"payer,omitempty"`
 
 		/**
 		 * Shipping address object of the agreement, which should be provided if it is different from the default address.
 		 */
-		ShippingAddress             Address `json:"shipping_address,omitempty"`
+		ShippingAddress             *Address `json:"shipping_address,omitempty"`
 
 		/**
 		 * Default merchant preferences from the billing plan are used, unless override preferences are provided here.
 		 */
-		OverrideMerchantPreferences MerchantPreferences `json:"override_merchant_preferences,omitempty"`
+		OverrideMerchantPreferences *MerchantPreferences `json:"override_merchant_preferences,omitempty"`
 
 		/**
 		 * Array of override_charge_model for this agreement if needed to change the default models from the billing plan.
@@ -119,7 +152,7 @@ type (
 		/**
 		 *
 		 */
-		Links                       []Link `json:"links,omitempty"`
+		Links                       Links  `json:"links,omitempty"`
 	}
 
 	AgreementDetails struct {
@@ -165,6 +198,31 @@ type (
 	}
 
 
+	// AgreementStateDescriptor is the body of suspend, re-activate, cancel and bill-balance calls of an agreement
+	AgreementStateDescriptor struct {
+		Note   string    `json:"note,omitempty"`
+		Amount *Currency `json:"amount,omitempty"`
+	}
+
+	// AgreementTransaction struct
+	AgreementTransaction struct {
+		TransactionID   string     `json:"transaction_id,omitempty"`
+		Status          string     `json:"status,omitempty"`
+		TransactionType string     `json:"transaction_type,omitempty"`
+		Amount          *Currency  `json:"amount,omitempty"`
+		FeeAmount       *Currency  `json:"fee_amount,omitempty"`
+		NetAmount       *Currency  `json:"net_amount,omitempty"`
+		PayerEmail      string     `json:"payer_email,omitempty"`
+		PayerName       string     `json:"payer_name,omitempty"`
+		TimeStamp       *time.Time `json:"time_stamp,omitempty"`
+		TimeZone        string     `json:"time_zone,omitempty"`
+	}
+
+	// AgreementTransactions GET /v1/payments/billing-agreements/ID/transactions
+	AgreementTransactions struct {
+		AgreementTransactionList []AgreementTransaction `json:"agreement_transaction_list"`
+	}
+
 	// Address struct
 	Address struct {
 		Line1       string `json:"line1"`
@@ -178,8 +236,9 @@ type (
 
 	// Amount struct
 	Amount struct {
-		Currency string `json:"currency"`
-		Total    string `json:"total"`
+		Currency string   `json:"currency"`
+		Total    string   `json:"total"`
+		Details  *Details `json:"details,omitempty"`
 	}
 
 	// AmountPayout struct
@@ -197,7 +256,7 @@ type (
 		ParentPayment             string     `json:"parent_payment,omitempty"`
 		ID                        string     `json:"id,omitempty"`
 		ValidUntil                *time.Time `json:"valid_until,omitempty"`
-		Links                     []Link     `json:"links,omitempty"`
+		Links                     Links      `json:"links,omitempty"`
 		ClearingTime              string     `json:"clearing_time,omitempty"`
 		ProtectionEligibility     string     `json:"protection_eligibility,omitempty"`
 		ProtectionEligibilityType string     `json:"protection_eligibility_type,omitempty"`
@@ -214,6 +273,22 @@ type (
 		SenderBatchHeader *SenderBatchHeader `json:"sender_batch_header,omitempty"`
 	}
 
+	// BillingPlans GET /v1/payments/billing-plans
+	BillingPlans struct {
+		Plans      []Plan `json:"plans"`
+		TotalItems string `json:"total_items,omitempty"`
+		TotalPages string `json:"total_pages,omitempty"`
+		Links      Links  `json:"links,omitempty"`
+	}
+
+	// BillingPlansFilter struct
+	BillingPlansFilter struct {
+		Status        string
+		Page          int
+		PageSize      int
+		TotalRequired bool
+	}
+
 	// Capture struct
 	Capture struct {
 		Amount         *Amount    `json:"amount,omitempty"`
@@ -223,7 +298,8 @@ type (
 		State          string     `json:"state,omitempty"`
 		ParentPayment  string     `json:"parent_payment,omitempty"`
 		ID             string     `json:"id,omitempty"`
-		Links          []Link     `json:"links,omitempty"`
+		TransactionFee *Currency  `json:"transaction_fee,omitempty"`
+		Links          Links      `json:"links,omitempty"`
 	}
 
 	ChargeModels struct {
@@ -251,6 +327,19 @@ type (
 		APIBase  string
 		Log      io.Writer // If user set log file name all requests will be logged there
 		Token    *TokenResponse
+		// RetryPolicy is used by Send to retry failed requests, nil means no retries
+		RetryPolicy *RetryPolicy
+		// TokenStore is consulted before requesting a new access token, nil means tokens are kept only in Token
+		TokenStore TokenStore
+
+		tokenMu      sync.Mutex // guards Token and tokenRefresh
+		tokenRefresh *tokenRefresh
+
+		// Set by ClientOption
+		timeout              time.Duration
+		userAgent            string
+		locale               string
+		partnerAttributionID string
 	}
 
 	// CreditCard struct
@@ -272,7 +361,7 @@ type (
 	// CreditCards GET /v1/vault/credit-cards
 	CreditCards struct {
 		Items      []CreditCard `json:"items"`
-		Links      []Link       `json:"links"`
+		Links      Links        `json:"links"`
 		TotalItems int          `json:"total_items"`
 		TotalPages int          `json:"total_pages"`
 	}
@@ -293,11 +382,9 @@ type (
 	}
 
 	// CreditCardField PATCH /v1/vault/credit-cards/credit_card_id
-	CreditCardField struct {
-		Operation string `json:"op"`
-		Path      string `json:"path"`
-		Value     string `json:"value"`
-	}
+	//
+	// Deprecated: use Patch
+	CreditCardField = PatchOperation
 
 	// Currency struct
 	Currency struct {
@@ -305,20 +392,42 @@ type (
 		Value    string `json:"value,omitempty"`
 	}
 
+	// Details is the breakdown of an Amount, the total must be the subtotal plus all charges minus the shipping discount
+	//
+	// https://developer.paypal.com/docs/api/payments/#definition-details
+	Details struct {
+		Subtotal         string `json:"subtotal,omitempty"`
+		Tax              string `json:"tax,omitempty"`
+		Shipping         string `json:"shipping,omitempty"`
+		HandlingFee      string `json:"handling_fee,omitempty"`
+		Insurance        string `json:"insurance,omitempty"`
+		ShippingDiscount string `json:"shipping_discount,omitempty"`
+	}
+
 	// ErrorResponse https://developer.paypal.com/docs/api/errors/
+	// DebugID is taken from PayPal-Debug-Id header when the body has none.
+	// OAuthError and ErrorDescription are set by /v1/oauth2/token and identity endpoints instead of Name and Message
 	ErrorResponse struct {
-		Response        *http.Response `json:"-"`
-		Name            string         `json:"name"`
-		DebugID         string         `json:"debug_id"`
-		Message         string         `json:"message"`
-		InformationLink string         `json:"information_link"`
-		Details         string         `json:"details"`
+		Response         *http.Response        `json:"-"`
+		Name             string                `json:"name"`
+		DebugID          string                `json:"debug_id"`
+		Message          string                `json:"message"`
+		InformationLink  string                `json:"information_link"`
+		Details          []ErrorResponseDetail `json:"details"`
+		OAuthError       string                `json:"error,omitempty"`
+		ErrorDescription string                `json:"error_description,omitempty"`
+	}
+
+	// ErrorResponseDetail describes the issue with one field of the request
+	ErrorResponseDetail struct {
+		Field string `json:"field"`
+		Issue string `json:"issue"`
 	}
 
 	// ExecuteResponse struct
 	ExecuteResponse struct {
 		ID           string        `json:"id"`
-		Links        []Link        `json:"links"`
+		Links        Links         `json:"links"`
 		State        string        `json:"state"`
 		Transactions []Transaction `json:"transactions,omitempty"`
 	}
@@ -358,52 +467,52 @@ type (
 		/**
 	 * Identifier of the merchant_preferences. 128 characters max.
 	 */
-		Id                      string `json:"id"`
+		Id                      string `json:"id,omitempty"`
 
 		/**
 		 * Setup fee amount. Default is 0.
 		 */
-		SetupFee                AmountPayout `json:"setup_fee"`
+		SetupFee                *AmountPayout `json:"setup_fee,omitempty"`
 
 		/**
 		 * Redirect URL on cancellation of agreement request. 1000 characters max.
 		 */
-		CancelUrl               string `json:"cancel_url"`
+		CancelUrl               string `json:"cancel_url,omitempty"`
 
 		/**
 		 * Redirect URL on creation of agreement request. 1000 characters max.
 		 */
-		ReturnUrl               string `json:"return_url"`
+		ReturnUrl               string `json:"return_url,omitempty"`
 
 		/**
 		 * Notify URL on agreement creation. 1000 characters max.
 		 */
-		NotifyUrl               string `json:"notify_url"`
+		NotifyUrl               string `json:"notify_url,omitempty"`
 
 		/**
 		 * Total number of failed attempts allowed. Default is 0, representing an infinite number of failed attempts.
 		 */
-		MaxFailAttempts         string `json:"max_fail_attempts"`
+		MaxFailAttempts         string `json:"max_fail_attempts,omitempty"`
 
 		/**
 		 * Allow auto billing for the outstanding amount of the agreement in the next cycle. Allowed values: `YES`, `NO`. Default is `NO`.
 		 */
-		AutoBillAmount          string `json:"auto_bill_amount"`
+		AutoBillAmount          string `json:"auto_bill_amount,omitempty"`
 
 		/**
 		 * Action to take if a failure occurs during initial payment. Allowed values: `CONTINUE`, `CANCEL`. Default is continue.
 		 */
-		InitialFailAmountAction string `json:"initial_fail_amount_action"`
+		InitialFailAmountAction string `json:"initial_fail_amount_action,omitempty"`
 
 		/**
 		 * Payment types that are accepted for this plan.
 		 */
-		AcceptedPaymentType     string `json:"accepted_payment_type"`
+		AcceptedPaymentType     string `json:"accepted_payment_type,omitempty"`
 
 		/**
 		 * char_set for this plan.
 		 */
-		CharSet                 string `json:"charset"`
+		CharSet                 string `json:"charset,omitempty"`
 	}
 
 	// Order struct
@@ -415,7 +524,7 @@ type (
 		Amount        *Amount    `json:"amount,omitempty"`
 		PendingReason string     `json:"pending_reason,omitempty"`
 		ParentPayment string     `json:"parent_payment,omitempty"`
-		Links         []Link     `json:"links,omitempty"`
+		Links         Links      `json:"links,omitempty"`
 	}
 
 	OverrideChargeModel struct {
@@ -498,7 +607,7 @@ type (
 		/**
 		 * Amount that will be charged at the end of each cycle for this payment definition.
 		 */
-		Amount            AmountPayout `json:"amount_payout,omitempty"`
+		Amount            AmountPayout `json:"amount,omitempty"`
 
 		/**
 		 * Array of charge_models for this payment definition.
@@ -509,7 +618,22 @@ type (
 	// PaymentResponse structure
 	PaymentResponse struct {
 		ID    string `json:"id"`
-		Links []Link `json:"links"`
+		Links Links  `json:"links"`
+	}
+
+	// PaymentsFilter selects the payments listed by GetPayments and IteratePayments
+	//
+	// https://developer.paypal.com/docs/api/payments/#payment_list
+	PaymentsFilter struct {
+		// Count is the number of payments per page, up to 20
+		Count      int
+		StartID    string
+		StartIndex int
+		StartTime  *time.Time
+		EndTime    *time.Time
+		SortBy     string
+		SortOrder  string
+		PayeeID    string
 	}
 
 	// Payout struct
@@ -520,30 +644,47 @@ type (
 
 	// PayoutItem struct
 	PayoutItem struct {
-		RecipientType string        `json:"recipient_type"`
-		Receiver      string        `json:"receiver"`
-		Amount        *AmountPayout `json:"amount"`
-		Note          string        `json:"note,omitempty"`
-		SenderItemID  string        `json:"sender_item_id,omitempty"`
+		RecipientType   string        `json:"recipient_type"`
+		Receiver        string        `json:"receiver"`
+		Amount          *AmountPayout `json:"amount"`
+		Note            string        `json:"note,omitempty"`
+		SenderItemID    string        `json:"sender_item_id,omitempty"`
+		RecipientWallet string        `json:"recipient_wallet,omitempty"`
+	}
+
+	// PayoutItemError is the reason of a failed payout item
+	PayoutItemError struct {
+		Name            string `json:"name"`
+		Message         string `json:"message"`
+		InformationLink string `json:"information_link,omitempty"`
 	}
 
 	// PayoutItemResponse struct
 	PayoutItemResponse struct {
-		PayoutItemID      string        `json:"payout_item_id"`
-		TransactionID     string        `json:"transaction_id"`
-		TransactionStatus string        `json:"transaction_status"`
-		PayoutBatchID     string        `json:"payout_batch_id,omitempty"`
-		PayoutItemFee     *AmountPayout `json:"payout_item_fee,omitempty"`
-		PayoutItem        *PayoutItem   `json:"payout_item"`
-		TimeProcessed     *time.Time    `json:"time_processed,omitempty"`
-		Links             []Link        `json:"links"`
+		PayoutItemID      string           `json:"payout_item_id"`
+		TransactionID     string           `json:"transaction_id"`
+		TransactionStatus string           `json:"transaction_status"`
+		PayoutBatchID     string           `json:"payout_batch_id,omitempty"`
+		PayoutItemFee     *AmountPayout    `json:"payout_item_fee,omitempty"`
+		PayoutItem        *PayoutItem      `json:"payout_item"`
+		TimeProcessed     *time.Time       `json:"time_processed,omitempty"`
+		Links             Links            `json:"links"`
+		Errors            *PayoutItemError `json:"errors,omitempty"`
 	}
 
 	// PayoutResponse struct
 	PayoutResponse struct {
 		BatchHeader *BatchHeader         `json:"batch_header"`
 		Items       []PayoutItemResponse `json:"items"`
-		Links       []Link               `json:"links"`
+		Links       Links                `json:"links"`
+		TotalItems  int                  `json:"total_items,omitempty"`
+		TotalPage   int                  `json:"total_page,omitempty"`
+	}
+
+	// PayoutBatchFilter struct
+	PayoutBatchFilter struct {
+		PageSize int
+		Page     int
 	}
 
 	Plan struct {
@@ -560,7 +701,7 @@ type (
 		/**
 		 * Description of the billing plan. 128 characters max.
 		 */
-		Description         string `json:"fescription,omitempty"`
+		Description         string `json:"description,omitempty"`
 
 		/**
 		 * Type of the billing plan. Allowed values: `FIXED`, `INFINITE`.
@@ -595,22 +736,27 @@ type (
 		/**
 		 * Specific preferences such as: set up fee, max fail attempts, autobill amount, and others that are configured for this billing plan.
 		 */
-		MerchantPreferences MerchantPreferences `json:"merchant_preferences,omitempty"`
+		MerchantPreferences *MerchantPreferences `json:"merchant_preferences,omitempty"`
 
 		/**
 		 *
 		 */
-		Links               []Link `json:"links,omitempty"`
+		Links               Links  `json:"links,omitempty"`
 	}
 
+	// PlanUpdateAttributes is a JSON patch operation for PATCH /v1/payments/billing-plans/ID
+	// Value is PlanState for state changes, or any other JSON value for the given Path
+	//
+	// Deprecated: use Patch with UpdateBillingPlan
 	PlanUpdateAttributes struct {
-		Op    string `json:"op,omitempty"`
-		Path  string `json:"path,omitempty"`
-		Value PlanState `json:"value,omitempty"`
+		Op    string      `json:"op,omitempty"`
+		Path  string      `json:"path,omitempty"`
+		Value interface{} `json:"value,omitempty"`
 	}
 
+	// PlanState is the value of a replace of "/" to change state of a plan
 	PlanState struct {
-		State string
+		State string `json:"state"`
 	}
 
 	// RedirectURLs struct
@@ -621,13 +767,30 @@ type (
 
 	// Refund struct
 	Refund struct {
-		ID            string     `json:"id,omitempty"`
-		Amount        *Amount    `json:"amount,omitempty"`
-		CreateTime    *time.Time `json:"create_time,omitempty"`
-		State         string     `json:"state,omitempty"`
-		CaptureID     string     `json:"capture_id,omitempty"`
-		ParentPayment string     `json:"parent_payment,omitempty"`
-		UpdateTime    *time.Time `json:"update_time,omitempty"`
+		ID                       string     `json:"id,omitempty"`
+		Amount                   *Amount    `json:"amount,omitempty"`
+		CreateTime               *time.Time `json:"create_time,omitempty"`
+		State                    string     `json:"state,omitempty"`
+		Reason                   string     `json:"reason,omitempty"`
+		ReasonCode               string     `json:"reason_code,omitempty"`
+		InvoiceNumber            string     `json:"invoice_number,omitempty"`
+		Description              string     `json:"description,omitempty"`
+		SaleID                   string     `json:"sale_id,omitempty"`
+		CaptureID                string     `json:"capture_id,omitempty"`
+		ParentPayment            string     `json:"parent_payment,omitempty"`
+		UpdateTime               *time.Time `json:"update_time,omitempty"`
+		RefundFromTransactionFee *Currency  `json:"refund_from_transaction_fee,omitempty"`
+		RefundFromReceivedAmount *Currency  `json:"refund_from_received_amount,omitempty"`
+		TotalRefundedAmount      *Currency  `json:"total_refunded_amount,omitempty"`
+		Links                    Links      `json:"links,omitempty"`
+	}
+
+	// RefundRequest is the body of refund calls, Amount is nil for a full refund
+	RefundRequest struct {
+		Amount        *Amount `json:"amount,omitempty"`
+		Description   string  `json:"description,omitempty"`
+		Reason        string  `json:"reason,omitempty"`
+		InvoiceNumber string  `json:"invoice_number,omitempty"`
 	}
 
 	// Related struct
@@ -654,12 +817,15 @@ type (
 		ClearingTime              string     `json:"clearing_time,omitempty"`
 		ProtectionEligibility     string     `json:"protection_eligibility,omitempty"`
 		ProtectionEligibilityType string     `json:"protection_eligibility_type,omitempty"`
-		Links                     []Link     `json:"links,omitempty"`
+		Links                     Links      `json:"links,omitempty"`
 	}
 
 	// SenderBatchHeader struct
 	SenderBatchHeader struct {
-		EmailSubject string `json:"email_subject"`
+		SenderBatchID string `json:"sender_batch_id,omitempty"`
+		EmailSubject  string `json:"email_subject,omitempty"`
+		EmailMessage  string `json:"email_message,omitempty"`
+		RecipientType string `json:"recipient_type,omitempty"`
 	}
 
 	// ShippingAddress struct
@@ -690,7 +856,7 @@ type (
 		/**
 		 * Max Amount associated with this term.
 		 */
-		MaxBillingAmount Amount `json:"max_billing_amount,omitempty"`
+		MaxBillingAmount *Amount `json:"max_billing_amount,omitempty"`
 
 		/**
 		 * How many times money can be pulled during this term.
@@ -700,7 +866,7 @@ type (
 		/**
 		 * Amount_range associated with this term.
 		 */
-		AmountRange      AmountPayout `json:"amount_range,omitempty"`
+		AmountRange      *AmountPayout `json:"amount_range,omitempty"`
 
 		/**
 		 * Buyer's ability to edit the amount in this term.
@@ -714,6 +880,8 @@ type (
 		Token        string `json:"access_token"`
 		Type         string `json:"token_type"`
 		ExpiresIn    int64  `json:"expires_in"`
+		// ExpiresAt is the time when the token expires, it's set by GetAccessToken
+		ExpiresAt time.Time `json:"expires_at,omitempty"`
 	}
 
 	// Transaction struct
@@ -747,6 +915,66 @@ type (
 		PayerID         string   `json:"payer_id,omitempty"`
 	}
 
+	// Webhook struct
+	Webhook struct {
+		ID         string             `json:"id,omitempty"`
+		URL        string             `json:"url"`
+		EventTypes []WebhookEventType `json:"event_types"`
+		Links      Links              `json:"links,omitempty"`
+	}
+
+	// WebhookEvent is a notification sent by PayPal to a webhook.
+	// Resource depends on ResourceType, e.g. sale, refund, authorization, capture or agreement
+	WebhookEvent struct {
+		ID           string          `json:"id"`
+		CreateTime   *time.Time      `json:"create_time,omitempty"`
+		ResourceType string          `json:"resource_type,omitempty"`
+		EventVersion string          `json:"event_version,omitempty"`
+		EventType    string          `json:"event_type"`
+		Summary      string          `json:"summary,omitempty"`
+		Status       string          `json:"status,omitempty"`
+		Resource     json.RawMessage `json:"resource,omitempty"`
+		Links        Links           `json:"links,omitempty"`
+	}
+
+	// WebhookEvents GET /v1/notifications/webhooks-events
+	WebhookEvents struct {
+		Events []WebhookEvent `json:"events"`
+		Count  int            `json:"count"`
+		Links  Links          `json:"links,omitempty"`
+	}
+
+	// WebhookEventsFilter struct
+	WebhookEventsFilter struct {
+		PageSize      int
+		StartTime     *time.Time
+		EndTime       *time.Time
+		TransactionID string
+		EventType     string
+	}
+
+	// WebhookEventType struct
+	WebhookEventType struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Status      string `json:"status,omitempty"`
+	}
+
+	// WebhookEventTypes GET /v1/notifications/webhooks-event-types
+	WebhookEventTypes struct {
+		EventTypes []WebhookEventType `json:"event_types"`
+	}
+
+	// WebhookField PATCH /v1/notifications/webhooks/webhook_id
+	//
+	// Deprecated: use Patch
+	WebhookField = PatchOperation
+
+	// Webhooks GET /v1/notifications/webhooks
+	Webhooks struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+
 	// WebProfile represents the configuration of the payment web payment experience
 	//
 	// https://developer.paypal.com/docs/api/payment-experience/
@@ -789,6 +1017,21 @@ type (
 )
 
 // Error method implementation for ErrorResponse struct
+// It doesn't panic when the response or its request is missing
 func (r *ErrorResponse) Error() string {
-	return fmt.Sprintf("%v %v: %d %s", r.Response.Request.Method, r.Response.Request.URL, r.Response.StatusCode, r.Message)
+	message := r.Message
+	if message == "" {
+		message = r.ErrorDescription
+	}
+	if message == "" {
+		message = r.Name
+	}
+
+	if r.Response == nil {
+		return fmt.Sprintf("paypalsdk: %s", message)
+	}
+	if r.Response.Request == nil {
+		return fmt.Sprintf("%d %s", r.Response.StatusCode, message)
+	}
+	return fmt.Sprintf("%v %v: %d %s", r.Response.Request.Method, r.Response.Request.URL, r.Response.StatusCode, message)
 }
@@ -1,11 +1,30 @@
 package paypalsdk
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"io/ioutil"
+	"math"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type webprofileTestServer struct {
@@ -287,6 +306,7 @@ func TestCreateWebProfile_valid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	wp := WebProfile{
 		Name: "YeowZa! T-Shirt Shop",
@@ -324,6 +344,7 @@ func TestCreateWebProfile_invalid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	wp := WebProfile{}
 
@@ -339,6 +360,7 @@ func TestGetWebProfile_valid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	res, err := c.GetWebProfile("XP-CP6S-W9DY-96H8-MVN2")
 
@@ -360,6 +382,7 @@ func TestGetWebProfile_invalid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	_, err := c.GetWebProfile("foobar")
 
@@ -373,6 +396,7 @@ func TestGetWebProfiles(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	res, err := c.GetWebProfiles()
 
@@ -390,6 +414,7 @@ func TestSetWebProfile_valid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	wp := WebProfile{
 		ID:   "XP-CP6S-W9DY-96H8-MVN2",
@@ -409,6 +434,7 @@ func TestSetWebProfile_invalid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	wp := WebProfile{
 		ID: "foobar",
@@ -434,6 +460,7 @@ func TestDeleteWebProfile_valid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	wp := WebProfile{
 		ID:   "XP-CP6S-W9DY-96H8-MVN2",
@@ -453,6 +480,7 @@ func TestDeleteWebProfile_invalid(t *testing.T) {
 	defer ts.Close()
 
 	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
 
 	err := c.DeleteWebProfile("foobar")
 
@@ -461,3 +489,1803 @@ func TestDeleteWebProfile_invalid(t *testing.T) {
 	}
 
 }
+
+func TestGetPaymentContext_cancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.GetPaymentContext(ctx, "PAY-123")
+	if err == nil {
+		t.Fatalf("expecting an error for cancelled context got nil")
+	}
+}
+
+func TestSendWithAuthContext_cancelsTokenRefresh(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.Token = &TokenResponse{Token: "expired", ExpiresAt: time.Now()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, _ := c.NewRequest("GET", ts.URL+"/v1/payments/payment/PAY-123", nil)
+	err := c.SendWithAuthContext(ctx, req, nil)
+	if err == nil {
+		t.Fatalf("expecting an error for cancelled context got nil")
+	}
+	if len(paths) != 0 {
+		t.Fatalf("expecting no requests to reach the server, got %v", paths)
+	}
+}
+
+func TestSend_retriesIdempotentRequests(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"PAY-123"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+
+	p, err := c.GetPayment("PAY-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "PAY-123" || calls != 3 {
+		t.Fatalf("expecting PAY-123 after 3 calls, got %q after %d calls", p.ID, calls)
+	}
+}
+
+func TestSend_retryErrorAttempts(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 4, MinBackoff: time.Hour})
+
+	_, err := c.GetSale("SALE-123")
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expecting *RetryError, got %v", err)
+	}
+	if retryErr.Attempts != 4 || calls != 4 {
+		t.Fatalf("expecting 4 attempts, got %d (%d calls)", retryErr.Attempts, calls)
+	}
+
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expecting wrapped *ErrorResponse with status 429, got %v", err)
+	}
+}
+
+func TestSend_doesNotRetryPost(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond})
+
+	_, err := c.CreateWebProfile(WebProfile{Name: "YeowZa!"})
+	if err == nil {
+		t.Fatalf("expecting an error got nil")
+	}
+	if calls != 1 {
+		t.Fatalf("expecting POST without PayPal-Request-Id to be sent once, got %d calls", calls)
+	}
+}
+
+func TestRefundSale_reusesRequestIDAcrossRetries(t *testing.T) {
+	var ids []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("PayPal-Request-Id"))
+		if len(ids) < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"id":"REFUND-123"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond})
+
+	ctx := WithRequestID(context.Background(), "refund-SALE-123")
+	_, err := c.RefundSaleContext(ctx, "SALE-123", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "refund-SALE-123" || ids[1] != "refund-SALE-123" {
+		t.Fatalf("expecting caller key on both attempts, got %v", ids)
+	}
+
+	ids = nil
+	c.SetRetryPolicy(nil)
+	c.CaptureAuthorization("AUTH-123", &Amount{Total: "7.00", Currency: "USD"}, true)
+	if len(ids) != 1 || len(ids[0]) != 36 {
+		t.Fatalf("expecting generated PayPal-Request-Id, got %v", ids)
+	}
+}
+
+func TestSendWithAuth_singleTokenRefresh(t *testing.T) {
+	var tokenCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/oauth2/token" {
+			atomic.AddInt32(&tokenCalls, 1)
+			time.Sleep(10 * time.Millisecond)
+			w.Write([]byte(`{"access_token":"fresh","expires_in":32400}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":"SALE-123"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.Token = &TokenResponse{Token: "old", ExpiresAt: time.Now().Add(-time.Minute)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetSale("SALE-123"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if tokenCalls != 1 {
+		t.Fatalf("expecting 1 token request, got %d", tokenCalls)
+	}
+	if c.Token.Token != "fresh" || c.Token.Expired() {
+		t.Fatalf("expecting fresh unexpired token, got %+v", c.Token)
+	}
+}
+
+func TestSendWithAuth_cancelledTokenRefreshLeader(t *testing.T) {
+	var tokenCalls int32
+	started, release := make(chan struct{}), make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/oauth2/token" {
+			if atomic.AddInt32(&tokenCalls, 1) == 1 {
+				close(started)
+			}
+			<-release
+			w.Write([]byte(`{"access_token":"fresh","expires_in":32400}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":"SALE-123"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.Token = &TokenResponse{Token: "old", ExpiresAt: time.Now().Add(-time.Minute)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := c.GetSaleContext(ctx, "SALE-123")
+		leaderErr <- err
+	}()
+
+	<-started
+	cancel()
+	if err := <-leaderErr; err != context.Canceled {
+		t.Fatalf("expecting the leader to be cancelled, got %v", err)
+	}
+
+	followerErr := make(chan error)
+	go func() {
+		_, err := c.GetSale("SALE-123")
+		followerErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if err := <-followerErr; err != nil {
+		t.Fatalf("expecting the follower to get the token of the cancelled leader's refresh, got %v", err)
+	}
+	if tokenCalls != 1 {
+		t.Fatalf("expecting 1 token request, got %d", tokenCalls)
+	}
+}
+
+func TestSendWithAuth_refreshesRejectedToken(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/oauth2/token" {
+			w.Write([]byte(`{"access_token":"fresh","expires_in":32400}`))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_token","error_description":"Access Token expired"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"REFUND-123"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("expired")
+
+	refund, err := c.RefundSale("SALE-123", &Amount{Total: "1.00", Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.ID != "REFUND-123" {
+		t.Fatalf("expecting REFUND-123, got %q", refund.ID)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] || bodies[1] == "" {
+		t.Fatalf("expecting the same body to be sent twice, got %q", bodies)
+	}
+}
+
+func TestTokenStore_sharedBetweenClients(t *testing.T) {
+	tokenCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/oauth2/token" {
+			tokenCalls++
+			w.Write([]byte(`{"access_token":"shared","expires_in":32400}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer shared" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":"SALE-123"}`))
+	}))
+	defer ts.Close()
+
+	store := NewMemoryTokenStore()
+	for i := 0; i < 3; i++ {
+		c, _ := NewClient("foo", "bar", ts.URL)
+		c.SetTokenStore(store)
+
+		if _, err := c.GetSale("SALE-123"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tokenCalls != 1 {
+		t.Fatalf("expecting 1 token request for 3 clients, got %d", tokenCalls)
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paypalsdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewFileTokenStore(filepath.Join(dir, "token.json"))
+
+	token, err := s.GetToken(context.Background())
+	if err != nil || token != nil {
+		t.Fatalf("expecting no token and no error for missing file, got %v, %v", token, err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour).Round(time.Second)
+	err = s.SetToken(context.Background(), &TokenResponse{Token: "abc", ExpiresIn: 3600, ExpiresAt: expiresAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = s.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "abc" || !token.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("expecting stored token with expiry, got %+v", token)
+	}
+}
+
+func TestNewClient_options(t *testing.T) {
+	var header http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"id":"SALE-123"}`))
+	}))
+	defer ts.Close()
+
+	hc := &http.Client{}
+	c, err := NewClient("foo", "bar", "", WithBaseURL(ts.URL), WithHTTPClient(hc), WithTimeout(time.Second),
+		WithUserAgent("shop/1.0"), WithLocale("de_DE"), WithPartnerAttributionID("SHOP_BN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hc.Timeout != 0 || c.client.Timeout != time.Second {
+		t.Fatalf("expecting a copy of http.Client with timeout, got %v and %v", hc.Timeout, c.client.Timeout)
+	}
+
+	if _, err = c.GetSale("SALE-123"); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("User-Agent") != "shop/1.0" ||
+		header.Get("Accept-Language") != "de_DE" ||
+		header.Get("PayPal-Partner-Attribution-Id") != "SHOP_BN" {
+		t.Fatalf("expecting headers from options, got %v", header)
+	}
+}
+
+func TestBillingPlans(t *testing.T) {
+	var query, patch string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			query = r.URL.RawQuery
+			w.Write([]byte(`{"plans":[{"id":"P-123","state":"ACTIVE","description":"Monthly"}],"total_items":"1","total_pages":"1"}`))
+		case "PATCH":
+			body, _ := ioutil.ReadAll(r.Body)
+			patch = string(body)
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	plans, err := c.ListBillingPlans(&BillingPlansFilter{Status: PlanStateActive, PageSize: 20, TotalRequired: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "page_size=20&status=ACTIVE&total_required=yes" {
+		t.Fatalf("unexpected query %q", query)
+	}
+	if len(plans.Plans) != 1 || plans.Plans[0].Id != "P-123" || plans.Plans[0].Description != "Monthly" {
+		t.Fatalf("unexpected plans %+v", plans)
+	}
+
+	if err = c.ActivatePlan("P-123"); err != nil {
+		t.Fatal(err)
+	}
+	if patch != `[{"op":"replace","path":"/","value":{"state":"ACTIVE"}}]` {
+		t.Fatalf("unexpected patch %s", patch)
+	}
+}
+
+func TestBillingAgreements(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+
+		switch r.URL.Path {
+		case "/v1/payments/billing-agreements":
+			w.Write([]byte(`{"name":"Monthly","links":[{"href":"https://www.sandbox.paypal.com/cgi-bin/webscr?cmd=_express-checkout&token=EC-123","rel":"approval_url","method":"REDIRECT"}]}`))
+		case "/v1/payments/billing-agreements/EC-123/agreement-execute":
+			w.Write([]byte(`{"id":"I-123","state":"Active","agreement_details":{"cycles_completed":"0"}}`))
+		case "/v1/payments/billing-agreements/I-123/transactions":
+			w.Write([]byte(`{"agreement_transaction_list":[{"transaction_id":"I-123","status":"Created","amount":{"currency":"USD","value":"10.00"}}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	a, err := c.CreateBillingAgreement(Agreement{
+		Name:      "Monthly",
+		StartDate: "2017-01-01T00:00:00Z",
+		Plan:      Plan{Id: "P-123"},
+		Payer:     &Payer{PaymentMethod: "paypal"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ApprovalURL() != "https://www.sandbox.paypal.com/cgi-bin/webscr?cmd=_express-checkout&token=EC-123" {
+		t.Fatalf("unexpected approval URL %q", a.ApprovalURL())
+	}
+	if requests[0] != `POST /v1/payments/billing-agreements {"name":"Monthly","start_date":"2017-01-01T00:00:00Z","payer":{"payment_method":"paypal"},"plan":{"id":"P-123"}}` {
+		t.Fatalf("unexpected request %s", requests[0])
+	}
+
+	a, err = c.ExecuteApprovedAgreement("EC-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Id != "I-123" || a.AgreementDetails.CyclesCompleted != "0" {
+		t.Fatalf("unexpected agreement %+v", a)
+	}
+
+	if err = c.SuspendBillingAgreement("I-123", "Paused by customer"); err != nil {
+		t.Fatal(err)
+	}
+	if requests[2] != `POST /v1/payments/billing-agreements/I-123/suspend {"note":"Paused by customer"}` {
+		t.Fatalf("unexpected request %s", requests[2])
+	}
+
+	from := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	txs, err := c.ListAgreementTransactions("I-123", from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests[3] != "GET /v1/payments/billing-agreements/I-123/transactions?end_date=2017-02-01&start_date=2017-01-01 " {
+		t.Fatalf("unexpected request %s", requests[3])
+	}
+	if len(txs.AgreementTransactionList) != 1 || txs.AgreementTransactionList[0].Amount.Value != "10.00" {
+		t.Fatalf("unexpected transactions %+v", txs)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+
+		switch r.URL.Path {
+		case "/v1/notifications/webhooks":
+			if r.Method == "POST" {
+				w.Write([]byte(`{"id":"WH-123","url":"https://example.com/paypal","event_types":[{"name":"PAYMENT.SALE.COMPLETED"}]}`))
+				return
+			}
+			w.Write([]byte(`{"webhooks":[{"id":"WH-123","url":"https://example.com/paypal"}]}`))
+		case "/v1/notifications/webhooks/WH-123":
+			if r.Method == "PATCH" {
+				w.Write([]byte(`{"id":"WH-123","url":"https://example.com/paypal","event_types":[{"name":"PAYMENT.SALE.REFUNDED"}]}`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/notifications/webhooks-event-types":
+			w.Write([]byte(`{"event_types":[{"name":"PAYMENT.SALE.COMPLETED","description":"A sale completes.","status":"ENABLED"}]}`))
+		case "/v1/notifications/webhooks-events":
+			w.Write([]byte(`{"events":[{"id":"WH-EVENT-1","event_type":"PAYMENT.SALE.COMPLETED","resource":{"id":"SALE-123"}}],"count":1}`))
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	wh, err := c.CreateWebhook(Webhook{URL: "https://example.com/paypal", EventTypes: []WebhookEventType{{Name: "PAYMENT.SALE.COMPLETED"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wh.ID != "WH-123" || requests[0] != `POST /v1/notifications/webhooks {"url":"https://example.com/paypal","event_types":[{"name":"PAYMENT.SALE.COMPLETED"}]}` {
+		t.Fatalf("unexpected webhook %+v for request %s", wh, requests[0])
+	}
+
+	whs, err := c.ListWebhooks()
+	if err != nil || len(whs) != 1 {
+		t.Fatalf("expecting 1 webhook, got %v, %v", whs, err)
+	}
+
+	wh, err = c.SetWebhookEventTypes("WH-123", []WebhookEventType{{Name: "PAYMENT.SALE.REFUNDED"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests[2] != `PATCH /v1/notifications/webhooks/WH-123 [{"op":"replace","path":"/event_types","value":[{"name":"PAYMENT.SALE.REFUNDED"}]}]` {
+		t.Fatalf("unexpected request %s", requests[2])
+	}
+
+	wh, err = c.UpdateWebhook("WH-123", Patch{}.Replace("/url", "https://example.com/paypal-new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests[3] != `PATCH /v1/notifications/webhooks/WH-123 [{"op":"replace","path":"/url","value":"https://example.com/paypal-new"}]` {
+		t.Fatalf("unexpected request %s", requests[3])
+	}
+
+	if err = c.DeleteWebhook("WH-123"); err != nil {
+		t.Fatal(err)
+	}
+
+	types, err := c.ListWebhookEventTypes()
+	if err != nil || len(types) != 1 || types[0].Status != "ENABLED" {
+		t.Fatalf("unexpected event types %v, %v", types, err)
+	}
+
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	events, err := c.ListWebhookEvents(&WebhookEventsFilter{PageSize: 5, StartTime: &start, EventType: "PAYMENT.SALE.COMPLETED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests[6] != "GET /v1/notifications/webhooks-events?event_type=PAYMENT.SALE.COMPLETED&page_size=5&start_time=2017-01-01T00%3A00%3A00Z " {
+		t.Fatalf("unexpected request %s", requests[6])
+	}
+	if events.Count != 1 || string(events.Events[0].Resource) != `{"id":"SALE-123"}` {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+type staticCertFetcher struct {
+	cert  *x509.Certificate
+	calls int
+}
+
+func (f *staticCertFetcher) FetchCert(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	f.calls++
+	return f.cert, nil
+}
+
+func newTestWebhookCert(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "messageverificationcerts.paypal.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, cert
+}
+
+func signTestWebhook(t *testing.T, key *rsa.PrivateKey, webhookID string, sent time.Time, body []byte) http.Header {
+	transmissionTime := sent.UTC().Format(time.RFC3339)
+	msg := fmt.Sprintf("%s|%s|%s|%d", "TX-1", transmissionTime, webhookID, crc32.ChecksumIEEE(body))
+	hash := sha256.Sum256([]byte(msg))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := http.Header{}
+	h.Set(HeaderTransmissionID, "TX-1")
+	h.Set(HeaderTransmissionTime, transmissionTime)
+	h.Set(HeaderTransmissionSig, base64.StdEncoding.EncodeToString(sig))
+	h.Set(HeaderCertURL, "https://api.paypal.com/v1/notifications/certs/CERT-360caa42")
+	h.Set(HeaderAuthAlgo, "SHA256withRSA")
+
+	return h
+}
+
+func TestLocalWebhookVerifier(t *testing.T) {
+	key, cert := newTestWebhookCert(t)
+	body := []byte(`{"id":"WH-EVENT-1","event_type":"PAYMENT.SALE.COMPLETED","resource":{"id":"SALE-123","state":"completed"}}`)
+	header := signTestWebhook(t, key, "WH-123", time.Now(), body)
+
+	fetcher := &staticCertFetcher{cert: cert}
+	v := NewLocalWebhookVerifier("WH-123")
+	v.CertFetcher = fetcher
+
+	if err := v.VerifyWebhook(context.Background(), header, body); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifyWebhook(context.Background(), header, body); err != nil {
+		t.Fatal(err)
+	}
+	if fetcher.calls != 1 {
+		t.Fatalf("expecting certificate to be fetched once, got %d", fetcher.calls)
+	}
+
+	tampered := []byte(`{"id":"WH-EVENT-1","event_type":"PAYMENT.SALE.COMPLETED","resource":{"id":"SALE-666"}}`)
+	if err := v.VerifyWebhook(context.Background(), header, tampered); err != ErrInvalidWebhookSignature {
+		t.Fatalf("expecting ErrInvalidWebhookSignature for tampered body, got %v", err)
+	}
+
+	replayed := signTestWebhook(t, key, "WH-123", time.Now().Add(-time.Hour), body)
+	if err := v.VerifyWebhook(context.Background(), replayed, body); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("expecting ErrInvalidWebhookSignature for notification sent an hour ago, got %v", err)
+	}
+
+	v.WebhookID = "WH-OTHER"
+	if err := v.VerifyWebhook(context.Background(), header, body); err != ErrInvalidWebhookSignature {
+		t.Fatalf("expecting ErrInvalidWebhookSignature for other webhook, got %v", err)
+	}
+}
+
+func TestHTTPCertFetcher_rejectsForeignURL(t *testing.T) {
+	f := &HTTPCertFetcher{}
+	if _, err := f.FetchCert(context.Background(), "https://evil.example.com/cert.pem"); err == nil {
+		t.Fatalf("expecting an error for non paypal.com URL")
+	}
+	if _, err := f.FetchCert(context.Background(), "http://api.paypal.com/v1/notifications/certs/CERT"); err == nil {
+		t.Fatalf("expecting an error for non HTTPS URL")
+	}
+}
+
+func TestRemoteWebhookVerifier(t *testing.T) {
+	var sent map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		if sent["transmission_id"] == "TX-1" {
+			w.Write([]byte(`{"verification_status":"SUCCESS"}`))
+			return
+		}
+		w.Write([]byte(`{"verification_status":"FAILURE"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+	v := NewRemoteWebhookVerifier(c, "WH-123")
+
+	body := []byte(`{"id":"WH-EVENT-1","event_type":"PAYMENT.SALE.COMPLETED"}`)
+	header := http.Header{}
+	header.Set(HeaderTransmissionID, "TX-1")
+
+	if err := v.VerifyWebhook(context.Background(), header, body); err != nil {
+		t.Fatal(err)
+	}
+	if sent["webhook_id"] != "WH-123" || sent["webhook_event"].(map[string]interface{})["id"] != "WH-EVENT-1" {
+		t.Fatalf("unexpected verify request %v", sent)
+	}
+
+	header.Set(HeaderTransmissionID, "TX-2")
+	if err := v.VerifyWebhook(context.Background(), header, body); err != ErrInvalidWebhookSignature {
+		t.Fatalf("expecting ErrInvalidWebhookSignature, got %v", err)
+	}
+}
+
+func TestWebhookEvent_DecodeResource(t *testing.T) {
+	tests := []struct {
+		body string
+		want interface{}
+	}{
+		{`{"id":"E-1","event_type":"PAYMENT.SALE.COMPLETED","resource":{"id":"SALE-1"}}`, &Sale{}},
+		{`{"id":"E-2","event_type":"PAYMENT.SALE.REFUNDED","resource":{"id":"REFUND-1"}}`, &Refund{}},
+		{`{"id":"E-3","event_type":"PAYMENT.AUTHORIZATION.CREATED","resource":{"id":"AUTH-1"}}`, &Authorization{}},
+		{`{"id":"E-4","event_type":"PAYMENT.CAPTURE.COMPLETED","resource":{"id":"CAPTURE-1"}}`, &Capture{}},
+		{`{"id":"E-5","event_type":"BILLING.SUBSCRIPTION.CANCELLED","resource":{"id":"I-1"}}`, &Agreement{}},
+		{`{"id":"E-6","event_type":"CUSTOMER.DISPUTE.CREATED","resource":{"id":"PP-1"}}`, json.RawMessage{}},
+	}
+
+	for _, test := range tests {
+		event, err := ParseWebhookEvent([]byte(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resource, err := event.DecodeResource()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := fmt.Sprintf("%T", resource), fmt.Sprintf("%T", test.want); got != want {
+			t.Errorf("expecting %s for %s, got %s", want, event.EventType, got)
+		}
+	}
+}
+
+type fakeWebhookVerifier struct {
+	err error
+}
+
+func (v *fakeWebhookVerifier) VerifyWebhook(ctx context.Context, header http.Header, body []byte) error {
+	return v.err
+}
+
+func TestWebhookHandler(t *testing.T) {
+	verifier := &fakeWebhookVerifier{}
+	h := NewWebhookHandler(verifier)
+
+	var sales []string
+	fail := true
+	h.OnSaleCompleted(func(ctx context.Context, event *WebhookEvent, sale *Sale) error {
+		if fail {
+			fail = false
+			return errors.New("database is down")
+		}
+		sales = append(sales, sale.ID)
+		return nil
+	})
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/paypal/webhook", strings.NewReader(body))
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	event := `{"id":"WH-EVENT-1","event_type":"PAYMENT.SALE.COMPLETED","resource":{"id":"SALE-123"}}`
+
+	if code := post(event); code != http.StatusInternalServerError {
+		t.Fatalf("expecting 500 for failed callback, got %d", code)
+	}
+	if code := post(event); code != http.StatusOK {
+		t.Fatalf("expecting 200 for redelivered event, got %d", code)
+	}
+	if code := post(event); code != http.StatusOK {
+		t.Fatalf("expecting 200 for duplicate event, got %d", code)
+	}
+	if len(sales) != 1 || sales[0] != "SALE-123" {
+		t.Fatalf("expecting SALE-123 to be handled once, got %v", sales)
+	}
+
+	if code := post(`{"id":"WH-EVENT-2","event_type":"PAYMENT.SALE.DENIED","resource":{}}`); code != http.StatusOK {
+		t.Fatalf("expecting 200 for event without callback, got %d", code)
+	}
+
+	verifier.err = ErrInvalidWebhookSignature
+	if code := post(event); code != http.StatusBadRequest {
+		t.Fatalf("expecting 400 for invalid signature, got %d", code)
+	}
+
+	verifier.err = errors.New("certificate download failed")
+	if code := post(event); code != http.StatusInternalServerError {
+		t.Fatalf("expecting 500 for failed verification, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/paypal/webhook", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expecting 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestWebhookHandler_literal(t *testing.T) {
+	h := &WebhookHandler{
+		Verifier:   &fakeWebhookVerifier{},
+		SeenEvents: &MemorySeenEventStore{TTL: time.Hour},
+	}
+
+	var handled int
+	h.On(EventPaymentSaleCompleted, func(ctx context.Context, event *WebhookEvent) error {
+		handled++
+		return nil
+	})
+
+	event := `{"id":"WH-EVENT-1","event_type":"PAYMENT.SALE.COMPLETED","resource":{"id":"SALE-123"}}`
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", "/paypal/webhook", strings.NewReader(event)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expecting 200, got %d", rec.Code)
+		}
+	}
+	if handled != 1 {
+		t.Fatalf("expecting the event to be handled once, got %d", handled)
+	}
+}
+
+func TestPollPayoutBatch(t *testing.T) {
+	var polls int
+	var created string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			body, _ := ioutil.ReadAll(r.Body)
+			created = string(body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"batch_header":{"payout_batch_id":"BATCH-1","batch_status":"PENDING"}}`))
+			return
+		}
+
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"batch_header":{"payout_batch_id":"BATCH-1","batch_status":"SUCCESS"},"items":[{"payout_item_id":"ITEM-2","transaction_status":"UNCLAIMED"}],"total_page":2}`))
+			return
+		}
+
+		polls++
+		if polls < 3 {
+			w.Write([]byte(`{"batch_header":{"payout_batch_id":"BATCH-1","batch_status":"PROCESSING"}}`))
+			return
+		}
+		w.Write([]byte(`{"batch_header":{"payout_batch_id":"BATCH-1","batch_status":"SUCCESS"},"items":[{"payout_item_id":"ITEM-1","transaction_status":"SUCCESS"}],"total_page":2}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	batch, err := c.CreateBatchPayout(Payout{
+		SenderBatchHeader: &SenderBatchHeader{SenderBatchID: "run-2017-01-01"},
+		Items: []PayoutItem{{
+			RecipientType:   "EMAIL",
+			Receiver:        "seller@example.com",
+			Amount:          &AmountPayout{Value: "15.11", Currency: "USD"},
+			RecipientWallet: "PAYPAL",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != `{"sender_batch_header":{"sender_batch_id":"run-2017-01-01"},"items":[{"recipient_type":"EMAIL","receiver":"seller@example.com","amount":{"currency":"USD","value":"15.11"},"recipient_wallet":"PAYPAL"}]}` {
+		t.Fatalf("unexpected request %s", created)
+	}
+
+	batch, err = c.PollPayoutBatch(context.Background(), batch.BatchHeader.PayoutBatchID, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polls != 3 || batch.BatchHeader.BatchStatus != BatchStatusSuccess {
+		t.Fatalf("expecting SUCCESS after 3 polls, got %s after %d", batch.BatchHeader.BatchStatus, polls)
+	}
+
+	items := batch.ItemsByStatus()
+	if len(items["SUCCESS"]) != 1 || len(items["UNCLAIMED"]) != 1 || items["UNCLAIMED"][0].PayoutItemID != "ITEM-2" {
+		t.Fatalf("unexpected items %+v", items)
+	}
+}
+
+func TestPollPayoutBatch_defaultInterval(t *testing.T) {
+	var polls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		w.Write([]byte(`{"batch_header":{"payout_batch_id":"BATCH-1","batch_status":"PROCESSING"}}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.PollPayoutBatch(ctx, "BATCH-1", 0)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expecting deadline exceeded, got %v", err)
+	}
+	if polls != 1 {
+		t.Fatalf("expecting a single poll with zero interval, got %d", polls)
+	}
+}
+
+func TestRefundCapture(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+		if r.Method == "GET" {
+			w.Write([]byte(`{"id":"CAPTURE-1","state":"completed","amount":{"currency":"USD","total":"10.00"},"transaction_fee":{"currency":"USD","value":"0.59"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"REFUND-1","state":"completed","capture_id":"CAPTURE-1","reason":"Damaged","invoice_number":"INV-1",` +
+			`"refund_from_received_amount":{"currency":"USD","value":"4.71"},"refund_from_transaction_fee":{"currency":"USD","value":"0.29"},` +
+			`"total_refunded_amount":{"currency":"USD","value":"5.00"}}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	capture, err := c.GetCapture("CAPTURE-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capture.State != "completed" || capture.TransactionFee.Value != "0.59" {
+		t.Fatalf("unexpected capture %+v", capture)
+	}
+
+	refund, err := c.RefundCapture("CAPTURE-1", &RefundRequest{
+		Amount:        &Amount{Total: "5.00", Currency: "USD"},
+		Reason:        "Damaged",
+		InvoiceNumber: "INV-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests[1] != `POST /v1/payments/capture/CAPTURE-1/refund {"amount":{"currency":"USD","total":"5.00"},"reason":"Damaged","invoice_number":"INV-1"}` {
+		t.Fatalf("unexpected request %s", requests[1])
+	}
+	if refund.CaptureID != "CAPTURE-1" || refund.RefundFromReceivedAmount.Value != "4.71" ||
+		refund.RefundFromTransactionFee.Value != "0.29" || refund.TotalRefundedAmount.Value != "5.00" {
+		t.Fatalf("unexpected refund %+v", refund)
+	}
+
+	if _, err = c.RefundCapture("CAPTURE-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if requests[2] != `POST /v1/payments/capture/CAPTURE-1/refund {}` {
+		t.Fatalf("unexpected request %s", requests[2])
+	}
+}
+
+func TestErrorResponse_details(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("PayPal-Debug-Id", "8f3c2d5e1b7a")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"name":"VALIDATION_ERROR","message":"Invalid request - see details","details":[{"field":"transactions[0].amount.total","issue":"Currency amount must be non-negative number"}]}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 1})
+
+	_, err := c.CreatePayment(Payment{Intent: "sale"})
+	errResp, ok := AsErrorResponse(err)
+	if !ok {
+		t.Fatalf("expecting *ErrorResponse, got %v", err)
+	}
+	if len(errResp.Details) != 1 || errResp.Details[0].Field != "transactions[0].amount.total" {
+		t.Fatalf("unexpected details %+v", errResp.Details)
+	}
+	if errResp.DebugID != "8f3c2d5e1b7a" {
+		t.Fatalf("expecting debug id from header, got %q", errResp.DebugID)
+	}
+	if !IsValidationError(err) || IsAuthError(err) {
+		t.Fatalf("expecting only validation error for %v", err)
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	newErr := func(status int, body string) error {
+		errResp := &ErrorResponse{Response: &http.Response{StatusCode: status}}
+		json.Unmarshal([]byte(body), errResp)
+		return errResp
+	}
+
+	tests := []struct {
+		err  error
+		is   func(error) bool
+		want bool
+	}{
+		{newErr(400, `{"name":"INSTRUMENT_DECLINED"}`), IsInstrumentDeclined, true},
+		{newErr(400, `{"name":"CREDIT_CARD_REFUSED"}`), IsInstrumentDeclined, true},
+		{newErr(400, `{"name":"VALIDATION_ERROR"}`), IsInstrumentDeclined, false},
+		{newErr(400, `{"name":"DUPLICATE_REQUEST_ID"}`), IsDuplicateRequest, true},
+		{newErr(400, `{"name":"DUPLICATE_TRANSACTION"}`), IsDuplicateRequest, true},
+		{newErr(429, `{"name":"RATE_LIMIT_REACHED"}`), IsRateLimited, true},
+		{newErr(429, ``), IsRateLimited, true},
+		{newErr(401, `{"error":"invalid_client","error_description":"Client Authentication failed"}`), IsAuthError, true},
+		{newErr(403, `{"name":"NOT_AUTHORIZED"}`), IsAuthError, true},
+		{&RetryError{Attempts: 3, Err: newErr(429, ``)}, IsRateLimited, true},
+		{errors.New("connection refused"), IsRateLimited, false},
+	}
+
+	for i, test := range tests {
+		if got := test.is(test.err); got != test.want {
+			t.Errorf("%d: expecting %v for %#v", i, test.want, test.err)
+		}
+	}
+}
+
+func TestErrorResponse_Error_withoutResponse(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.sandbox.paypal.com/v1/payments/payment/PAY-1", nil)
+
+	tests := []struct {
+		err  *ErrorResponse
+		want string
+	}{
+		{&ErrorResponse{Message: "boom"}, "paypalsdk: boom"},
+		{&ErrorResponse{Response: &http.Response{StatusCode: 500}, Name: "INTERNAL_SERVICE_ERROR"}, "500 INTERNAL_SERVICE_ERROR"},
+		{&ErrorResponse{Response: &http.Response{StatusCode: 401, Request: req}, ErrorDescription: "Client Authentication failed"}, "GET https://api.sandbox.paypal.com/v1/payments/payment/PAY-1: 401 Client Authentication failed"},
+	}
+
+	for i, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("%d: expecting %q, got %q", i, test.want, got)
+		}
+	}
+}
+
+func TestPaymentHelpers_withoutToken(t *testing.T) {
+	var tokenCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/oauth2/token" {
+			tokenCalls++
+			w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer abc" {
+			t.Errorf("expecting Bearer abc for %s, got %q", r.URL.Path, auth)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/payments/payment":
+			w.Write([]byte(`{"id":"PAY-1","state":"created"}`))
+		case "/v1/payments/payment/PAY-1/execute":
+			w.Write([]byte(`{"id":"PAY-1","state":"approved"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+
+	p, err := c.CreateDirectPaypalPayment(Amount{Total: "7.00", Currency: "USD"}, "http://example.com/return", "http://example.com/cancel", "Test")
+	if err != nil || p.ID != "PAY-1" {
+		t.Fatalf("CreateDirectPaypalPayment: %+v, %v", p, err)
+	}
+
+	e, err := c.ExecuteApprovedPayment("PAY-1", "PAYER-1")
+	if err != nil || e.ID != "PAY-1" {
+		t.Fatalf("ExecuteApprovedPayment: %+v, %v", e, err)
+	}
+	if tokenCalls != 1 {
+		t.Fatalf("expecting 1 token request, got %d", tokenCalls)
+	}
+}
+
+func TestSendWithAuth_fetchesTokenOnFirstUse(t *testing.T) {
+	var tokenCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/oauth2/token" {
+			atomic.AddInt32(&tokenCalls, 1)
+			w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"id":"PAY-1"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetPayment("PAY-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&tokenCalls); n != 1 {
+		t.Fatalf("expecting 1 token request, got %d", n)
+	}
+}
+
+func TestRequestBodies_escapeCallerInput(t *testing.T) {
+	bodies := make(map[string]map[string]interface{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]interface{})
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s: invalid JSON body: %v", r.URL.Path, err)
+		}
+		bodies[r.URL.Path] = body
+		w.Write([]byte(`{"id":"PAY-1"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	description := `Shoes", "intent": "authorize`
+	_, err := c.CreateDirectPaypalPayment(Amount{Total: "7.00", Currency: "USD"}, `http://example.com/return?q=a\b`, "http://example.com/cancel", description,
+		WithPaymentItems(Item{Quantity: 1, Name: "Shoes", Price: "7.00", Currency: "USD"}),
+		WithExperienceProfileID("XP-1"),
+		WithInvoiceNumber("INV-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ExecuteApprovedPayment("PAY-1", `PAYER"1`, Transaction{Amount: &Amount{Total: "8.00", Currency: "USD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.CaptureAuthorization("AUTH-1", &Amount{Total: "7.00", Currency: `USD"`}, true)
+	c.ReauthorizeAuthorization("AUTH-1", &Amount{Total: "7.00", Currency: "USD"})
+
+	payment := bodies["/v1/payments/payment"]
+	transaction := payment["transactions"].([]interface{})[0].(map[string]interface{})
+	if payment["intent"] != "sale" || transaction["description"] != description || transaction["invoice_number"] != "INV-1" {
+		t.Errorf("unexpected payment body %v", payment)
+	}
+	if payment["experience_profile_id"] != "XP-1" || transaction["item_list"] == nil {
+		t.Errorf("expecting payment options in body %v", payment)
+	}
+	if payment["redirect_urls"].(map[string]interface{})["return_url"] != `http://example.com/return?q=a\b` {
+		t.Errorf("unexpected redirect urls %v", payment["redirect_urls"])
+	}
+
+	execute := bodies["/v1/payments/payment/PAY-1/execute"]
+	if execute["payer_id"] != `PAYER"1` || len(execute["transactions"].([]interface{})) != 1 {
+		t.Errorf("unexpected execute body %v", execute)
+	}
+
+	capture := bodies["/v1/payments/authorization/AUTH-1/capture"]
+	if capture["is_final_capture"] != true || capture["amount"].(map[string]interface{})["currency"] != `USD"` {
+		t.Errorf("unexpected capture body %v", capture)
+	}
+	if bodies["/v1/payments/authorization/AUTH-1/reauthorize"]["amount"] == nil {
+		t.Errorf("expecting amount in reauthorize body")
+	}
+}
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		value, currency, want string
+	}{
+		{"10.5", "USD", "10.50"},
+		{"10", "usd", "10.00"},
+		{"0.07", "EUR", "0.07"},
+		{"-3.1", "USD", "-3.10"},
+		{"1050", "JPY", "1050"},
+		{"1.5", "KWD", "1.500"},
+		{"2500", "HUF", "2500"},
+		{"300", "twd", "300"},
+	}
+	for _, test := range tests {
+		m, err := ParseMoney(test.value, test.currency)
+		if err != nil {
+			t.Fatalf("ParseMoney(%q, %q): %v", test.value, test.currency, err)
+		}
+		if m.String() != test.want {
+			t.Errorf("ParseMoney(%q, %q) = %s, expecting %s", test.value, test.currency, m, test.want)
+		}
+	}
+
+	for _, value := range []string{"10.505", "", ".5", "10.", "1,00", "1e3", "--1"} {
+		if _, err := ParseMoney(value, "USD"); !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("expecting ErrInvalidMoney for %q, got %v", value, err)
+		}
+	}
+	if _, err := ParseMoney("1050.5", "JPY"); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("expecting ErrInvalidMoney for JPY with decimals, got %v", err)
+	}
+	if _, err := ParseMoney("2500.50", "HUF"); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("expecting ErrInvalidMoney for HUF with decimals, got %v", err)
+	}
+
+	price, _ := Amount{Total: "19.99", Currency: "USD"}.Money()
+	total, _ := price.Mul(3)
+	shipping, _ := AmountPayout{Value: "5", Currency: "USD"}.Money()
+	total, _ = total.Add(shipping)
+	total, _ = total.Sub(NewMoney(97, "USD"))
+	if a := total.Amount(); a.Total != "64.00" || a.Currency != "USD" {
+		t.Errorf("unexpected total %+v", a)
+	}
+	if _, err := total.Add(NewMoney(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("expecting ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := NewMoney(math.MaxInt64, "USD").Add(NewMoney(1, "USD")); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("expecting ErrMoneyOverflow, got %v", err)
+	}
+
+	parts, err := NewMoney(1000, "USD").Allocate(1, 1, 1)
+	if err != nil || parts[0].String() != "3.34" || parts[1].String() != "3.33" || parts[2].String() != "3.33" {
+		t.Errorf("unexpected allocation %v, %v", parts, err)
+	}
+	parts, _ = NewMoney(-5, "JPY").Allocate(1, 0, 1)
+	if parts[0].Units() != -3 || parts[1].Units() != 0 || parts[2].Units() != -2 {
+		t.Errorf("unexpected allocation %v", parts)
+	}
+	if p := NewMoney(-5, "USD").AmountPayout(); p.Value != "-0.05" {
+		t.Errorf("unexpected payout amount %+v", p)
+	}
+}
+
+func TestPayment_Validate(t *testing.T) {
+	// https://developer.paypal.com/docs/api/payments/v1/#payment_create
+	p := Payment{
+		Intent: "sale",
+		Transactions: []Transaction{{
+			Amount: &Amount{
+				Currency: "USD",
+				Total:    "30.11",
+				Details: &Details{Subtotal: "30.00", Tax: "0.07", Shipping: "0.03", HandlingFee: "1.00",
+					ShippingDiscount: "-1.00", Insurance: "0.01"},
+			},
+			Description: strings.Repeat("ü", maxDescriptionLength),
+			ItemList: &ItemList{Items: []Item{
+				{Quantity: 5, Name: "hat", Price: "3", Tax: "0.01", SKU: "1", Currency: "USD"},
+				{Quantity: 1, Name: "handbag", Price: "15", Tax: "0.02", SKU: "product34", Currency: "USD"},
+			}},
+		}},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("expecting valid payment, got %v", err)
+	}
+
+	p.Transactions[0].Amount.Details.ShippingDiscount = "1.00"
+	err := p.Validate()
+	if errs, ok := err.(ValidationErrors); !ok || len(errs) != 1 || errs[0].Field != "transactions[0].amount.details.shipping_discount" {
+		t.Fatalf("expecting issue for positive shipping discount, got %v", err)
+	}
+	p.Transactions[0].Amount.Details.ShippingDiscount = "-1.00"
+
+	p.Transactions = append(p.Transactions, Transaction{
+		Amount:         &Amount{Currency: "JPY", Total: "1000", Details: &Details{Subtotal: "900", Shipping: "50"}},
+		SoftDescriptor: strings.Repeat("x", 23),
+		ItemList: &ItemList{Items: []Item{
+			{Quantity: 1, Name: "Tea", Price: "500", Currency: "JPY"},
+			{Quantity: 1, Name: "Cup", Price: "399.5", Currency: "JPY"},
+			{Quantity: 1, Name: "Pot", Price: "10", Currency: "USD"},
+		}},
+	})
+	err = p.Validate()
+	if !IsValidationError(err) {
+		t.Fatalf("expecting ValidationErrors, got %v", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, d := range err.(ValidationErrors) {
+		fields[d.Field] = true
+	}
+	for _, field := range []string{
+		"transactions[1].soft_descriptor",
+		"transactions[1].item_list.items[1].price",
+		"transactions[1].item_list.items[2].currency",
+		"transactions[1].amount.total",
+	} {
+		if !fields[field] {
+			t.Errorf("expecting issue for %s in %v", field, err)
+		}
+	}
+	if len(fields) != 4 {
+		t.Errorf("unexpected issues %v", err)
+	}
+
+	tr := Transaction{
+		Amount:   &Amount{Currency: "USD", Total: "10.00"},
+		ItemList: &ItemList{Items: []Item{{Quantity: 3, Name: "Pen", Price: "3.00", Currency: "USD"}}},
+	}
+	err = tr.Validate()
+	if errs, ok := err.(ValidationErrors); !ok || len(errs) != 1 || errs[0].Field != "amount.total" || errs[0].Issue != "must be 9.00, the sum of the items" {
+		t.Errorf("unexpected transaction issues %v", err)
+	}
+}
+
+func TestIteratePayments(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		switch r.URL.Query().Get("start_id") {
+		case "":
+			w.Write([]byte(`{"payments":[{"id":"PAY-1"},{"id":"PAY-2"}],"count":2,"next_id":"PAY-3"}`))
+		case "PAY-3":
+			w.Write([]byte(`{"payments":[{"id":"PAY-3"}],"count":1,"links":[{"href":"/v1/payments/payment?count=2&start_id=PAY-4","rel":"next"}]}`))
+		case "PAY-4":
+			w.Write([]byte(`{"payments":[],"count":0}`))
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	var ids []string
+	it := c.IteratePayments(&PaymentsFilter{Count: 2})
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Value().ID)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if strings.Join(ids, ",") != "PAY-1,PAY-2,PAY-3" {
+		t.Errorf("unexpected payments %v", ids)
+	}
+	if len(requests) != 3 || requests[0] != "/v1/payments/payment?count=2" || requests[1] != "/v1/payments/payment?count=2&start_id=PAY-3" {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
+
+func TestIterateCreditCards(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`{"items":[{"id":"CARD-1"}],"total_items":3,"total_pages":3}`))
+		case "2":
+			w.Write([]byte(`{"items":[{"id":"CARD-2"}],"total_items":3,"total_pages":3,"links":[{"href":"https://api.paypal.com/v1/vault/credit-cards?page=3","rel":"next"}]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	var ids []string
+	it := c.IterateCreditCards(&CreditCardsFilter{PageSize: 1})
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Value().ID)
+	}
+	if strings.Join(ids, ",") != "CARD-1,CARD-2" {
+		t.Errorf("unexpected cards %v", ids)
+	}
+	if it.Err() == nil || it.Next(context.Background()) {
+		t.Errorf("expecting iteration to stop with an error for a link to another host")
+	}
+}
+
+func TestIterateWebProfiles(t *testing.T) {
+	ts := httptest.NewServer(&webprofileTestServer{t: t})
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	n := 0
+	it := c.IterateWebProfiles()
+	for it.Next(context.Background()) {
+		if it.Value().ID == "" {
+			t.Errorf("expecting web profile id")
+		}
+		n++
+	}
+	if it.Err() != nil || n != 2 {
+		t.Errorf("expecting 2 web profiles, got %d, %v", n, it.Err())
+	}
+}
+
+func TestGetPayments_filter(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"payments":[{"id":"PAY-1"}],"count":1,"next_id":"PAY-2"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	start := time.Date(2016, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	payments, err := c.GetPayments(&PaymentsFilter{
+		Count:      10,
+		StartIndex: 5,
+		StartTime:  &start,
+		EndTime:    &end,
+		SortBy:     PaymentsSortByUpdateTime,
+		SortOrder:  SortOrderAsc,
+		PayeeID:    "PAYEE-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payments.Count != 1 || payments.NextID != "PAY-2" || len(payments.Payments) != 1 {
+		t.Errorf("unexpected response %+v", payments)
+	}
+
+	want := "count=10&end_time=2016-11-01T00%3A00%3A00Z&payee_id=PAYEE-1&sort_by=update_time&sort_order=asc&start_index=5&start_time=2016-10-01T00%3A00%3A00Z"
+	if query != want {
+		t.Errorf("expecting query %s, got %s", want, query)
+	}
+}
+
+func TestUpdatePayment(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/v1/payments/payment/PAY-1" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"id":"PAY-1","state":"created"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	patch := Patch{}.
+		Replace(TransactionAmountPath(0), Amount{Total: "9.00", Currency: "USD"}).
+		Add(TransactionInvoiceNumberPath(0), "INV-1").
+		Remove(TransactionCustomPath(1))
+	p, err := c.UpdatePayment("PAY-1", patch)
+	if err != nil || p.ID != "PAY-1" {
+		t.Fatalf("unexpected result %+v, %v", p, err)
+	}
+
+	want := `[{"op":"replace","path":"/transactions/0/amount","value":{"currency":"USD","total":"9.00"}},` +
+		`{"op":"add","path":"/transactions/0/invoice_number","value":"INV-1"},` +
+		`{"op":"remove","path":"/transactions/1/custom"}]`
+	if body != want {
+		t.Errorf("expecting body %s, got %s", want, body)
+	}
+
+	if path := PatchPath("a/b", "m~n", 2); path != "/a~1b/m~0n/2" {
+		t.Errorf("unexpected escaped path %s", path)
+	}
+}
+
+func TestPatchCreditCardAndBillingPlan(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, b))
+		w.Write([]byte(`{"id":"CARD-1"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	card, err := c.PatchCreditCard("CARD-1", Patch{}.Replace(PatchPath("billing_address", "line1"), "52 N Main ST"))
+	if err != nil || card.ID != "CARD-1" {
+		t.Fatalf("unexpected result %+v, %v", card, err)
+	}
+	if err = c.UpdateBillingPlan("P-1", Patch{}.Remove("/description")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`PATCH /v1/vault/credit-cards/CARD-1 [{"op":"replace","path":"/billing_address/line1","value":"52 N Main ST"}]`,
+		`PATCH /v1/payments/billing-plans/P-1 [{"op":"remove","path":"/description"}]`,
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expecting requests %q, got %q", want, requests)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s %t", r.Method, r.URL.Path, b, r.Header.Get("PayPal-Request-Id") != ""))
+		switch r.URL.Path {
+		case "/v1/payments/sale/SALE-1":
+			w.Write([]byte(`{"id":"SALE-1","state":"completed"}`))
+		case "/v1/payments/sale/SALE-1/refund":
+			w.Write([]byte(`{"id":"REFUND-1","state":"completed"}`))
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	sale := Sale{Links: Links{
+		{Href: ts.URL + "/v1/payments/sale/SALE-1", Rel: LinkRelSelf, Method: "GET"},
+		{Href: ts.URL + "/v1/payments/sale/SALE-1/refund", Rel: LinkRelRefund, Method: "POST"},
+		{Href: "https://www.paypal.com/cgi-bin/webscr?cmd=_express-checkout", Rel: LinkRelApprovalURL, Method: "REDIRECT"},
+	}}
+
+	self, ok := sale.Links.Self()
+	if !ok {
+		t.Fatal("expecting self link")
+	}
+	refreshed := &Sale{}
+	if err := c.Follow(self, refreshed); err != nil || refreshed.State != "completed" {
+		t.Fatalf("unexpected sale %+v, %v", refreshed, err)
+	}
+
+	refundLink, _ := sale.Links.Find(LinkRelRefund)
+	refund := &Refund{}
+	if err := c.Follow(refundLink, refund); err == nil {
+		t.Fatalf("expecting error for following a POST link without a payload")
+	}
+	if err := c.FollowWithBody(refundLink, nil, refund); err == nil {
+		t.Fatalf("expecting error for a nil payload")
+	}
+	partial := &RefundRequest{Amount: &Amount{Total: "1.00", Currency: "USD"}}
+	if err := c.FollowWithBody(refundLink, partial, refund); err != nil || refund.ID != "REFUND-1" {
+		t.Fatalf("unexpected refund %+v, %v", refund, err)
+	}
+
+	if sale.Links.ApprovalURL() == "" || sale.Links.Href(LinkRelNext) != "" {
+		t.Errorf("unexpected hrefs of %v", sale.Links)
+	}
+	approval, _ := sale.Links.Find(LinkRelApprovalURL)
+	if err := c.Follow(approval, nil); err == nil {
+		t.Errorf("expecting error for a link outside of the API")
+	}
+
+	want := []string{"GET /v1/payments/sale/SALE-1  false", `POST /v1/payments/sale/SALE-1/refund {"amount":{"currency":"USD","total":"1.00"}} true`}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected requests %q", requests)
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	executedTotal := "10.00"
+	n := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/payments/payment":
+			n++
+			fmt.Fprintf(w, `{"id":"PAY-%d","state":"created","links":[{"href":"https://www.sandbox.paypal.com/cgi-bin/webscr?cmd=_express-checkout&token=EC-%d","rel":"approval_url","method":"REDIRECT"}]}`, n, n)
+		case "/v1/payments/payment/PAY-1/execute", "/v1/payments/payment/PAY-2/execute":
+			id := strings.Split(r.URL.Path, "/")[4]
+			fmt.Fprintf(w, `{"id":"%s","state":"approved","transactions":[{"amount":{"total":"%s","currency":"USD"}}]}`, id, executedTotal)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+	co := NewCheckout(c)
+
+	payment := Payment{
+		Intent:       "sale",
+		Transactions: []Transaction{{Amount: &Amount{Total: "10", Currency: "USD"}}},
+		RedirectURLs: &RedirectURLs{ReturnURL: "https://shop.example.com/return", CancelURL: "https://shop.example.com/cancel"},
+	}
+
+	approvalURL, token, err := co.Begin(payment)
+	if err != nil || token != "EC-1" || !strings.Contains(approvalURL, "token=EC-1") {
+		t.Fatalf("unexpected Begin result %s, %s, %v", approvalURL, token, err)
+	}
+
+	returnReq := httptest.NewRequest("GET", "https://shop.example.com/return?paymentId=PAY-1&token=EC-1&PayerID=PAYER-1", nil)
+	executed, err := co.Complete(returnReq)
+	if err != nil || executed.ID != "PAY-1" {
+		t.Fatalf("unexpected Complete result %+v, %v", executed, err)
+	}
+	if _, err = co.Complete(returnReq); !errors.Is(err, ErrCheckoutNotFound) {
+		t.Errorf("expecting ErrCheckoutNotFound for completed checkout, got %v", err)
+	}
+
+	_, token, _ = co.Begin(payment)
+	executedTotal = "1.00"
+	_, err = co.Complete(httptest.NewRequest("GET", "https://shop.example.com/return?paymentId=PAY-2&token="+token+"&PayerID=PAYER-1", nil))
+	if !errors.Is(err, ErrCheckoutMismatch) {
+		t.Errorf("expecting ErrCheckoutMismatch for a different amount, got %v", err)
+	}
+
+	_, token, _ = co.Begin(payment)
+	_, err = co.Complete(httptest.NewRequest("GET", "https://shop.example.com/cancel?token="+token, nil))
+	if !errors.Is(err, ErrCheckoutCancelled) {
+		t.Errorf("expecting ErrCheckoutCancelled, got %v", err)
+	}
+	if err = co.Cancel(httptest.NewRequest("GET", "https://shop.example.com/cancel?token="+token, nil)); !errors.Is(err, ErrCheckoutNotFound) {
+		t.Errorf("expecting ErrCheckoutNotFound for cancelled checkout, got %v", err)
+	}
+
+	payment.RedirectURLs = nil
+	if _, _, err = co.Begin(payment); err == nil {
+		t.Errorf("expecting error for payment without redirect URLs")
+	}
+}
+
+func TestMemoryCheckoutStore_returnsCopies(t *testing.T) {
+	ctx := context.Background()
+	s := &MemoryCheckoutStore{TTL: time.Hour}
+
+	session := &CheckoutSession{Token: "EC-1", PaymentID: "PAY-1", Amounts: []Amount{{Total: "10.00", Currency: "USD"}}, CreatedAt: time.Now()}
+	if err := s.SaveCheckout(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	session.PaymentID = "PAY-2"
+
+	loaded, _ := s.GetCheckout(ctx, "EC-1")
+	loaded.Amounts[0].Total = "0.01"
+
+	again, _ := s.GetCheckout(ctx, "EC-1")
+	if again.PaymentID != "PAY-1" || again.Amounts[0].Total != "10.00" {
+		t.Fatalf("expecting the stored session to be unchanged, got %+v", again)
+	}
+}
+
+type failingDeleteCheckoutStore struct {
+	*MemoryCheckoutStore
+}
+
+func (s failingDeleteCheckoutStore) DeleteCheckout(ctx context.Context, token string) error {
+	return errors.New("store is down")
+}
+
+func TestCheckout_verifiesBeforeDelete(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/payments/payment" {
+			w.Write([]byte(`{"id":"PAY-1","state":"created","links":[{"href":"https://www.sandbox.paypal.com/cgi-bin/webscr?cmd=_express-checkout&token=EC-1","rel":"approval_url","method":"REDIRECT"}]}`))
+			return
+		}
+		w.Write([]byte(`{"id":"PAY-1","state":"failed","transactions":[{"amount":{"total":"10.00","currency":"USD"}}]}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+	co := &Checkout{Client: c, Store: failingDeleteCheckoutStore{NewMemoryCheckoutStore(time.Hour)}}
+
+	_, token, err := co.Begin(Payment{
+		Intent:       "sale",
+		Transactions: []Transaction{{Amount: &Amount{Total: "10.00", Currency: "USD"}}},
+		RedirectURLs: &RedirectURLs{ReturnURL: "https://shop.example.com/return", CancelURL: "https://shop.example.com/cancel"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = co.Complete(httptest.NewRequest("GET", "https://shop.example.com/return?paymentId=PAY-1&token="+token+"&PayerID=PAYER-1", nil))
+	if !errors.Is(err, ErrCheckoutMismatch) {
+		t.Errorf("expecting ErrCheckoutMismatch ahead of the store error, got %v", err)
+	}
+}
+
+func TestAuthorizationManager(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/reauthorize"):
+			w.Write([]byte(`{"id":"AUTH-1R","state":"authorized","create_time":"2016-10-05T00:00:00Z"}`))
+		case strings.HasSuffix(r.URL.Path, "/capture"):
+			w.Write([]byte(`{"id":"CAPTURE-1","state":"completed"}`))
+		case strings.HasSuffix(r.URL.Path, "/void"):
+			w.Write([]byte(`{"state":"voided"}`))
+		}
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	created := time.Date(2016, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := created.Add(time.Hour)
+	m := NewAuthorizationManager(c, AuthorizationPolicy{Reauthorize: true, ExpiryAction: AuthorizationActionVoid, ExpiryWindow: 24 * time.Hour})
+	m.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for _, id := range []string{"AUTH-1", "AUTH-2"} {
+		_, err := m.Track(ctx, &Authorization{ID: id, Amount: &Amount{Total: "100.00", Currency: "USD"}, CreateTime: &created})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	due, _ := m.Due(ctx, 3*24*time.Hour)
+	if len(due) != 2 {
+		t.Errorf("expecting both authorizations to be due at the end of the honor period, got %d", len(due))
+	}
+	if _, err := m.Reauthorize(ctx, "AUTH-1"); err == nil {
+		t.Errorf("expecting error for reauthorization in honor period")
+	}
+
+	if _, err := m.Capture(ctx, "AUTH-2", NewMoney(4000, "USD"), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Capture(ctx, "AUTH-2", NewMoney(7600, "USD"), false); err == nil {
+		t.Errorf("expecting error for capture over 115%%")
+	}
+	if _, err := m.Capture(ctx, "AUTH-2", NewMoney(7500, "USD"), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Capture(ctx, "AUTH-2", NewMoney(100, "USD"), false); !errors.Is(err, ErrAuthorizationNotTracked) {
+		t.Errorf("expecting ErrAuthorizationNotTracked for closed authorization, got %v", err)
+	}
+
+	now = created.Add(4 * 24 * time.Hour)
+	actions, err := m.Process(ctx)
+	if err != nil || len(actions) != 1 || actions[0].Action != AuthorizationActionReauthorize || actions[0].Err != nil {
+		t.Fatalf("expecting reauthorization, got %+v, %v", actions, err)
+	}
+	if _, err = m.Reauthorize(ctx, "AUTH-1"); err == nil {
+		t.Errorf("expecting error for second reauthorization")
+	}
+
+	now = created.Add(28*24*time.Hour + time.Hour)
+	actions, _ = m.Process(ctx)
+	if len(actions) != 1 || actions[0].Action != AuthorizationActionVoid || actions[0].Err != nil {
+		t.Fatalf("expecting void before expiry, got %+v", actions)
+	}
+
+	want := []string{
+		"/v1/payments/authorization/AUTH-2/capture",
+		"/v1/payments/authorization/AUTH-2/capture",
+		"/v1/payments/authorization/AUTH-1/reauthorize",
+		"/v1/payments/authorization/AUTH-1R/void",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
+
+type failingSaveAuthorizationStore struct {
+	*MemoryAuthorizationStore
+	fail bool
+}
+
+func (s *failingSaveAuthorizationStore) SaveAuthorization(ctx context.Context, r *AuthorizationRecord) error {
+	if s.fail {
+		return errors.New("store is down")
+	}
+	return s.MemoryAuthorizationStore.SaveAuthorization(ctx, r)
+}
+
+func TestAuthorizationManager_captureErrors(t *testing.T) {
+	store := &failingSaveAuthorizationStore{MemoryAuthorizationStore: NewMemoryAuthorizationStore()}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store.fail = true
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"name":"VALIDATION_ERROR","message":"Invalid request"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+	m := &AuthorizationManager{Client: c, Store: store}
+
+	ctx := context.Background()
+	created := time.Now()
+	if _, err := m.Track(ctx, &Authorization{ID: "AUTH-1", Amount: &Amount{Total: "100000000000000.00", Currency: "USD"}, CreateTime: &created}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Capture(ctx, "AUTH-1", NewMoney(100000000000000000, "USD"), false); !errors.Is(err, ErrMoneyOverflow) {
+		t.Fatalf("expecting ErrMoneyOverflow for a capture over the limit, got %v", err)
+	}
+
+	_, err := m.Capture(ctx, "AUTH-1", NewMoney(100, "USD"), false)
+	if !IsValidationError(err) || !strings.Contains(err.Error(), "store is down") {
+		t.Fatalf("expecting the rejection and the failed save, got %v", err)
+	}
+	r, _ := store.LoadAuthorization(ctx, "AUTH-1")
+	if r.PendingOperation == "" {
+		t.Fatalf("expecting the pending operation to be kept in the store, got %+v", r)
+	}
+}
+
+func TestAuthorizationManager_retriedCaptureReusesRequestID(t *testing.T) {
+	var ids []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("PayPal-Request-Id"))
+		if len(ids) == 1 {
+			// the capture is done but its response is lost
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"id":"CAPTURE-1","state":"completed"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewClient("foo", "bar", ts.URL)
+	c.SetAccessToken("abc")
+
+	// a literal manager uses the current time
+	m := &AuthorizationManager{Client: c, Store: NewMemoryAuthorizationStore()}
+	created := time.Now().Add(-time.Hour)
+
+	ctx := WithRequestID(context.Background(), "shared-by-caller")
+	if _, err := m.Track(ctx, &Authorization{ID: "AUTH-1", Amount: &Amount{Total: "100.00", Currency: "USD"}, CreateTime: &created}); err != nil {
+		t.Fatal(err)
+	}
+	if due, err := m.Due(ctx, time.Hour); err != nil || len(due) != 0 {
+		t.Fatalf("expecting nothing due, got %v, %v", due, err)
+	}
+
+	if _, err := m.Capture(ctx, "AUTH-1", NewMoney(4000, "USD"), false); err == nil {
+		t.Fatalf("expecting an error for the lost response")
+	}
+	if _, err := m.Capture(ctx, "AUTH-1", NewMoney(4000, "USD"), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Capture(ctx, "AUTH-1", NewMoney(4000, "USD"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 3 || ids[0] == "" || ids[0] != ids[1] {
+		t.Fatalf("expecting the retried capture to reuse the PayPal-Request-Id, got %q", ids)
+	}
+	if ids[2] == ids[1] || ids[2] == "shared-by-caller" {
+		t.Fatalf("expecting a new PayPal-Request-Id for the next capture, got %q", ids)
+	}
+
+	r, _ := m.Store.LoadAuthorization(ctx, "AUTH-1")
+	if r.Captured.Total != "80.00" || r.PendingOperation != "" {
+		t.Fatalf("expecting 80.00 captured with nothing pending, got %+v", r)
+	}
+}
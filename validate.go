@@ -0,0 +1,222 @@
+package paypalsdk
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Maximum lengths of transaction fields accepted by the API
+const (
+	maxDescriptionLength    = 127
+	maxInvoiceNumberLength  = 127
+	maxCustomLength         = 255
+	maxSoftDescriptorLength = 22
+	maxItemNameLength       = 127
+	maxItemSKULength        = 127
+)
+
+// ValidationErrors lists the issues found by Payment.Validate and Transaction.Validate.
+// Fields are paths in the format of ErrorResponseDetail, e.g. "transactions[0].item_list.items[1].price"
+type ValidationErrors []ErrorResponseDetail
+
+// Error method implementation for ValidationErrors
+func (e ValidationErrors) Error() string {
+	issues := make([]string, len(e))
+	for i, d := range e {
+		issues[i] = d.Field + ": " + d.Issue
+	}
+
+	return "paypalsdk: invalid request: " + strings.Join(issues, "; ")
+}
+
+// amountValidator collects the issues of a request
+type amountValidator struct {
+	errs ValidationErrors
+}
+
+func (v *amountValidator) add(field string, format string, args ...interface{}) {
+	v.errs = append(v.errs, ErrorResponseDetail{Field: field, Issue: fmt.Sprintf(format, args...)})
+}
+
+// parse parses value of field, an empty value is zero unless the field is required
+func (v *amountValidator) parse(field string, value string, currency string, required bool) (Money, bool) {
+	if value == "" && !required {
+		return NewMoney(0, currency), true
+	}
+
+	m, err := ParseMoney(value, currency)
+	if err != nil {
+		v.add(field, "must be a valid amount with at most %d decimals for %s", CurrencyExponent(currency), currency)
+		return m, false
+	}
+
+	return m, true
+}
+
+// money parses the non-negative amount of field, see parse
+func (v *amountValidator) money(field string, value string, currency string, required bool) (Money, bool) {
+	m, ok := v.parse(field, value, currency, required)
+	if ok && m.IsNegative() {
+		v.add(field, "must not be negative")
+		return m, false
+	}
+
+	return m, ok
+}
+
+// discount parses the optional discount of field, which PayPal expects as a negative amount
+func (v *amountValidator) discount(field string, value string, currency string) (Money, bool) {
+	m, ok := v.parse(field, value, currency, false)
+	if ok && !m.IsNegative() && !m.IsZero() {
+		v.add(field, "must not be positive")
+		return m, false
+	}
+
+	return m, ok
+}
+
+// addTo adds m × quantity to sum, an overflow is reported as an issue of field
+func (v *amountValidator) addTo(sum *Money, m Money, quantity int, field string) bool {
+	m, err := m.Mul(int64(quantity))
+	if err == nil {
+		*sum, err = sum.Add(m)
+	}
+	if err != nil {
+		v.add(field, "%v", err)
+		return false
+	}
+
+	return true
+}
+
+func (v *amountValidator) length(field string, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.add(field, "must be at most %d characters long", max)
+	}
+}
+
+// Validate checks the transactions of the payment, see Transaction.Validate
+func (p *Payment) Validate() error {
+	v := &amountValidator{}
+	if len(p.Transactions) == 0 {
+		v.add("transactions", "at least one transaction is required")
+	}
+	for i := range p.Transactions {
+		p.Transactions[i].validate(v, fmt.Sprintf("transactions[%d].", i))
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	return nil
+}
+
+// Validate checks the transaction before it's sent to the API:
+// the amounts must be valid for the currency and items must have the currency of the transaction,
+// the items (price × quantity) must add up to the subtotal and their tax (tax × quantity) to the tax,
+// the subtotal, tax, shipping, handling fee, insurance and the negative shipping discount must add up to the total
+// and the text fields must not be too long. It returns ValidationErrors with all issues found
+func (t *Transaction) Validate() error {
+	v := &amountValidator{}
+	t.validate(v, "")
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	return nil
+}
+
+func (t *Transaction) validate(v *amountValidator, prefix string) {
+	v.length(prefix+"description", t.Description, maxDescriptionLength)
+	v.length(prefix+"invoice_number", t.InvoiceNumber, maxInvoiceNumberLength)
+	v.length(prefix+"custom", t.Custom, maxCustomLength)
+	v.length(prefix+"soft_descriptor", t.SoftDescriptor, maxSoftDescriptorLength)
+
+	if t.Amount == nil {
+		v.add(prefix+"amount", "is required")
+		return
+	}
+	currency := strings.ToUpper(t.Amount.Currency)
+	if len(currency) != 3 {
+		v.add(prefix+"amount.currency", "must be a 3-letter ISO 4217 code")
+		return
+	}
+
+	total, totalOK := v.money(prefix+"amount.total", t.Amount.Total, currency, true)
+
+	itemsOK := true
+	itemsTotal, itemsTax := NewMoney(0, currency), NewMoney(0, currency)
+	itemsHaveTax := false
+	if t.ItemList != nil {
+		for i, item := range t.ItemList.Items {
+			field := fmt.Sprintf("%sitem_list.items[%d].", prefix, i)
+
+			if item.Name == "" {
+				v.add(field+"name", "is required")
+			}
+			v.length(field+"name", item.Name, maxItemNameLength)
+			v.length(field+"sku", item.SKU, maxItemSKULength)
+			v.length(field+"description", item.Description, maxDescriptionLength)
+			if !strings.EqualFold(item.Currency, currency) {
+				v.add(field+"currency", "must be %s like the transaction amount", currency)
+				itemsOK = false
+				continue
+			}
+			price, priceOK := v.money(field+"price", item.Price, currency, true)
+			tax, taxOK := v.money(field+"tax", item.Tax, currency, false)
+			if item.Quantity <= 0 {
+				v.add(field+"quantity", "must be greater than 0")
+				itemsOK = false
+				continue
+			}
+			if !priceOK || !taxOK {
+				itemsOK = false
+				continue
+			}
+
+			itemsHaveTax = itemsHaveTax || item.Tax != ""
+			itemsOK = v.addTo(&itemsTotal, price, item.Quantity, field+"price") &&
+				v.addTo(&itemsTax, tax, item.Quantity, field+"tax") && itemsOK
+		}
+	}
+	hasItems := t.ItemList != nil && len(t.ItemList.Items) > 0
+
+	d := t.Amount.Details
+	if d == nil {
+		if hasItems && itemsOK && totalOK && itemsTotal.Units() != total.Units() {
+			v.add(prefix+"amount.total", "must be %s, the sum of the items", itemsTotal)
+		}
+		return
+	}
+
+	field := prefix + "amount.details."
+	subtotal, ok := v.money(field+"subtotal", d.Subtotal, currency, hasItems)
+	tax, taxOK := v.money(field+"tax", d.Tax, currency, false)
+	shipping, shippingOK := v.money(field+"shipping", d.Shipping, currency, false)
+	handlingFee, handlingFeeOK := v.money(field+"handling_fee", d.HandlingFee, currency, false)
+	insurance, insuranceOK := v.money(field+"insurance", d.Insurance, currency, false)
+	discount, discountOK := v.discount(field+"shipping_discount", d.ShippingDiscount, currency)
+
+	if hasItems && itemsOK && ok && itemsTotal.Units() != subtotal.Units() {
+		v.add(field+"subtotal", "must be %s, the sum of the items", itemsTotal)
+	}
+	if itemsHaveTax && itemsOK && taxOK && itemsTax.Units() != tax.Units() {
+		v.add(field+"tax", "must be %s, the sum of the item taxes", itemsTax)
+	}
+
+	if !totalOK || !ok || !taxOK || !shippingOK || !handlingFeeOK || !insuranceOK || !discountOK {
+		return
+	}
+	sum := NewMoney(0, currency)
+	for _, m := range []Money{subtotal, tax, shipping, handlingFee, insurance, discount} {
+		if !v.addTo(&sum, m, 1, prefix+"amount.total") {
+			return
+		}
+	}
+	if sum.Units() != total.Units() {
+		v.add(prefix+"amount.total", "must be %s, the subtotal plus charges and the shipping discount", sum)
+	}
+}
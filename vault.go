@@ -1,13 +1,19 @@
 package paypalsdk
 
 import (
+	"context"
 	"fmt"
 )
 
 // StoreCreditCard func
 // Endpoint: POST /v1/vault/credit-cards
 func (c *Client) StoreCreditCard(cc CreditCard) (*CreditCard, error) {
-	req, err := c.NewRequest("POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/vault/credit-cards"), cc)
+	return c.StoreCreditCardContext(context.Background(), cc)
+}
+
+// StoreCreditCardContext is like StoreCreditCard but uses ctx for the request
+func (c *Client) StoreCreditCardContext(ctx context.Context, cc CreditCard) (*CreditCard, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/vault/credit-cards"), cc)
 	if err != nil {
 		return nil, err
 	}
@@ -24,7 +30,12 @@ func (c *Client) StoreCreditCard(cc CreditCard) (*CreditCard, error) {
 // DeleteCreditCard func
 // Endpoint: DELETE /v1/vault/credit-cards/credit_card_id
 func (c *Client) DeleteCreditCard(id string) error {
-	req, err := c.NewRequest("DELETE", fmt.Sprintf("%s/v1/vault/credit-cards/%s", c.APIBase, id), nil)
+	return c.DeleteCreditCardContext(context.Background(), id)
+}
+
+// DeleteCreditCardContext is like DeleteCreditCard but uses ctx for the request
+func (c *Client) DeleteCreditCardContext(ctx context.Context, id string) error {
+	req, err := c.NewRequestContext(ctx, "DELETE", fmt.Sprintf("%s/v1/vault/credit-cards/%s", c.APIBase, id), nil)
 	if err != nil {
 		return err
 	}
@@ -40,7 +51,12 @@ func (c *Client) DeleteCreditCard(id string) error {
 // GetCreditCard func
 // Endpoint: GET /v1/vault/credit-cards/credit_card_id
 func (c *Client) GetCreditCard(id string) (*CreditCard, error) {
-	req, err := c.NewRequest("GET", fmt.Sprintf("%s/v1/vault/credit-cards/%s", c.APIBase, id), nil)
+	return c.GetCreditCardContext(context.Background(), id)
+}
+
+// GetCreditCardContext is like GetCreditCard but uses ctx for the request
+func (c *Client) GetCreditCardContext(ctx context.Context, id string) (*CreditCard, error) {
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s/v1/vault/credit-cards/%s", c.APIBase, id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -57,6 +73,11 @@ func (c *Client) GetCreditCard(id string) (*CreditCard, error) {
 // GetCreditCards func
 // Endpoint: GET /v1/vault/credit-cards
 func (c *Client) GetCreditCards(ccf *CreditCardsFilter) (*CreditCards, error) {
+	return c.GetCreditCardsContext(context.Background(), ccf)
+}
+
+// GetCreditCardsContext is like GetCreditCards but uses ctx for the request
+func (c *Client) GetCreditCardsContext(ctx context.Context, ccf *CreditCardsFilter) (*CreditCards, error) {
 	page := 1
 	if ccf != nil && ccf.Page > 0 {
 		page = ccf.Page
@@ -66,7 +87,7 @@ func (c *Client) GetCreditCards(ccf *CreditCardsFilter) (*CreditCards, error) {
 		pageSize = ccf.PageSize
 	}
 
-	req, err := c.NewRequest("GET", fmt.Sprintf("%s/v1/vault/credit-cards?page=%d&page_size=%d", c.APIBase, page, pageSize), nil)
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s/v1/vault/credit-cards?page=%d&page_size=%d", c.APIBase, page, pageSize), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -80,16 +101,16 @@ func (c *Client) GetCreditCards(ccf *CreditCardsFilter) (*CreditCards, error) {
 	return &response, nil
 }
 
-// PatchCreditCard func
+// PatchCreditCard applies JSON patch operations to a stored credit card
 // Endpoint: PATCH /v1/vault/credit-cards/credit_card_id
-func (c *Client) PatchCreditCard(id string, ccf []CreditCardField) (*CreditCard, error) {
-	req, err := c.NewRequest("PATCH", fmt.Sprintf("%s/v1/vault/credit-cards/%s", c.APIBase, id), ccf)
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) PatchCreditCard(id string, patch Patch) (*CreditCard, error) {
+	return c.PatchCreditCardContext(context.Background(), id, patch)
+}
 
+// PatchCreditCardContext is like PatchCreditCard but uses ctx for the request
+func (c *Client) PatchCreditCardContext(ctx context.Context, id string, patch Patch) (*CreditCard, error) {
 	response := CreditCard{}
-	err = c.SendWithAuth(req, &response)
+	err := c.sendPatch(ctx, fmt.Sprintf("%s/v1/vault/credit-cards/%s", c.APIBase, id), patch, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,327 @@
+package paypalsdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types of webhook notifications
+//
+// https://developer.paypal.com/docs/integration/direct/webhooks/event-names/
+const (
+	EventPaymentAuthorizationCreated    = "PAYMENT.AUTHORIZATION.CREATED"
+	EventPaymentAuthorizationVoided     = "PAYMENT.AUTHORIZATION.VOIDED"
+	EventPaymentCaptureCompleted        = "PAYMENT.CAPTURE.COMPLETED"
+	EventPaymentCaptureDenied           = "PAYMENT.CAPTURE.DENIED"
+	EventPaymentCapturePending          = "PAYMENT.CAPTURE.PENDING"
+	EventPaymentCaptureRefunded         = "PAYMENT.CAPTURE.REFUNDED"
+	EventPaymentCaptureReversed         = "PAYMENT.CAPTURE.REVERSED"
+	EventPaymentSaleCompleted           = "PAYMENT.SALE.COMPLETED"
+	EventPaymentSaleDenied              = "PAYMENT.SALE.DENIED"
+	EventPaymentSalePending             = "PAYMENT.SALE.PENDING"
+	EventPaymentSaleRefunded            = "PAYMENT.SALE.REFUNDED"
+	EventPaymentSaleReversed            = "PAYMENT.SALE.REVERSED"
+	EventBillingSubscriptionCreated     = "BILLING.SUBSCRIPTION.CREATED"
+	EventBillingSubscriptionCancelled   = "BILLING.SUBSCRIPTION.CANCELLED"
+	EventBillingSubscriptionReActivated = "BILLING.SUBSCRIPTION.RE-ACTIVATED"
+	EventBillingSubscriptionSuspended   = "BILLING.SUBSCRIPTION.SUSPENDED"
+	EventBillingSubscriptionUpdated     = "BILLING.SUBSCRIPTION.UPDATED"
+)
+
+// CreateWebhook subscribes the URL to the given event types, use "*" as name to subscribe to all events
+// Endpoint: POST /v1/notifications/webhooks
+func (c *Client) CreateWebhook(wh Webhook) (*Webhook, error) {
+	return c.CreateWebhookContext(context.Background(), wh)
+}
+
+// CreateWebhookContext is like CreateWebhook but uses ctx for the request
+func (c *Client) CreateWebhookContext(ctx context.Context, wh Webhook) (*Webhook, error) {
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks"), wh)
+	if err != nil {
+		return &Webhook{}, err
+	}
+	setRequestID(req)
+
+	response := &Webhook{}
+
+	err = c.SendWithAuth(req, response)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// GetWebhook returns a webhook by ID
+// Endpoint: GET /v1/notifications/webhooks/ID
+func (c *Client) GetWebhook(webhookID string) (*Webhook, error) {
+	return c.GetWebhookContext(context.Background(), webhookID)
+}
+
+// GetWebhookContext is like GetWebhook but uses ctx for the request
+func (c *Client) GetWebhookContext(ctx context.Context, webhookID string) (*Webhook, error) {
+	wh := &Webhook{}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks/"+webhookID), nil)
+	if err != nil {
+		return wh, err
+	}
+
+	err = c.SendWithAuth(req, wh)
+	if err != nil {
+		return wh, err
+	}
+
+	return wh, nil
+}
+
+// ListWebhooks returns all webhooks of the application
+// Endpoint: GET /v1/notifications/webhooks
+func (c *Client) ListWebhooks() ([]Webhook, error) {
+	return c.ListWebhooksContext(context.Background())
+}
+
+// ListWebhooksContext is like ListWebhooks but uses ctx for the request
+func (c *Client) ListWebhooksContext(ctx context.Context) ([]Webhook, error) {
+	var whs Webhooks
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks"), nil)
+	if err != nil {
+		return whs.Webhooks, err
+	}
+
+	err = c.SendWithAuth(req, &whs)
+	if err != nil {
+		return whs.Webhooks, err
+	}
+
+	return whs.Webhooks, nil
+}
+
+// UpdateWebhook applies JSON patch operations to a webhook, only /url and /event_types can be replaced
+// Endpoint: PATCH /v1/notifications/webhooks/ID
+func (c *Client) UpdateWebhook(webhookID string, patch Patch) (*Webhook, error) {
+	return c.UpdateWebhookContext(context.Background(), webhookID, patch)
+}
+
+// UpdateWebhookContext is like UpdateWebhook but uses ctx for the request
+func (c *Client) UpdateWebhookContext(ctx context.Context, webhookID string, patch Patch) (*Webhook, error) {
+	response := &Webhook{}
+
+	err := c.sendPatch(ctx, fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks/"+webhookID), patch, response)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// SetWebhookEventTypes replaces event types the webhook is subscribed to
+// Endpoint: PATCH /v1/notifications/webhooks/ID
+func (c *Client) SetWebhookEventTypes(webhookID string, eventTypes []WebhookEventType) (*Webhook, error) {
+	return c.SetWebhookEventTypesContext(context.Background(), webhookID, eventTypes)
+}
+
+// SetWebhookEventTypesContext is like SetWebhookEventTypes but uses ctx for the request
+func (c *Client) SetWebhookEventTypesContext(ctx context.Context, webhookID string, eventTypes []WebhookEventType) (*Webhook, error) {
+	return c.UpdateWebhookContext(ctx, webhookID, Patch{}.Replace("/event_types", eventTypes))
+}
+
+// DeleteWebhook deletes a webhook by ID
+// Endpoint: DELETE /v1/notifications/webhooks/ID
+func (c *Client) DeleteWebhook(webhookID string) error {
+	return c.DeleteWebhookContext(context.Background(), webhookID)
+}
+
+// DeleteWebhookContext is like DeleteWebhook but uses ctx for the request
+func (c *Client) DeleteWebhookContext(ctx context.Context, webhookID string) error {
+	req, err := c.NewRequestContext(ctx, "DELETE", fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks/"+webhookID), nil)
+	if err != nil {
+		return err
+	}
+
+	err = c.SendWithAuth(req, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListWebhookSubscriptions returns event types the webhook is subscribed to
+// Endpoint: GET /v1/notifications/webhooks/ID/event-types
+func (c *Client) ListWebhookSubscriptions(webhookID string) ([]WebhookEventType, error) {
+	return c.ListWebhookSubscriptionsContext(context.Background(), webhookID)
+}
+
+// ListWebhookSubscriptionsContext is like ListWebhookSubscriptions but uses ctx for the request
+func (c *Client) ListWebhookSubscriptionsContext(ctx context.Context, webhookID string) ([]WebhookEventType, error) {
+	return c.listEventTypes(ctx, fmt.Sprintf("%s/v1/notifications/webhooks/%s/event-types", c.APIBase, webhookID))
+}
+
+// ListWebhookEventTypes returns all event types available for subscription
+// Endpoint: GET /v1/notifications/webhooks-event-types
+func (c *Client) ListWebhookEventTypes() ([]WebhookEventType, error) {
+	return c.ListWebhookEventTypesContext(context.Background())
+}
+
+// ListWebhookEventTypesContext is like ListWebhookEventTypes but uses ctx for the request
+func (c *Client) ListWebhookEventTypesContext(ctx context.Context) ([]WebhookEventType, error) {
+	return c.listEventTypes(ctx, fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks-event-types"))
+}
+
+// ListWebhookEvents returns webhook events, by default the events of the last 30 days
+// Endpoint: GET /v1/notifications/webhooks-events
+func (c *Client) ListWebhookEvents(wef *WebhookEventsFilter) (*WebhookEvents, error) {
+	return c.ListWebhookEventsContext(context.Background(), wef)
+}
+
+// ListWebhookEventsContext is like ListWebhookEvents but uses ctx for the request
+func (c *Client) ListWebhookEventsContext(ctx context.Context, wef *WebhookEventsFilter) (*WebhookEvents, error) {
+	q := url.Values{}
+	if wef != nil {
+		if wef.PageSize > 0 {
+			q.Set("page_size", strconv.Itoa(wef.PageSize))
+		}
+		if wef.StartTime != nil {
+			q.Set("start_time", wef.StartTime.UTC().Format(time.RFC3339))
+		}
+		if wef.EndTime != nil {
+			q.Set("end_time", wef.EndTime.UTC().Format(time.RFC3339))
+		}
+		if wef.TransactionID != "" {
+			q.Set("transaction_id", wef.TransactionID)
+		}
+		if wef.EventType != "" {
+			q.Set("event_type", wef.EventType)
+		}
+	}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s/v1/notifications/webhooks-events?%s", c.APIBase, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := WebhookEvents{}
+	err = c.SendWithAuth(req, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// GetWebhookEvent returns a webhook event by ID
+// Endpoint: GET /v1/notifications/webhooks-events/ID
+func (c *Client) GetWebhookEvent(eventID string) (*WebhookEvent, error) {
+	return c.GetWebhookEventContext(context.Background(), eventID)
+}
+
+// GetWebhookEventContext is like GetWebhookEvent but uses ctx for the request
+func (c *Client) GetWebhookEventContext(ctx context.Context, eventID string) (*WebhookEvent, error) {
+	event := &WebhookEvent{}
+
+	req, err := c.NewRequestContext(ctx, "GET", fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks-events/"+eventID), nil)
+	if err != nil {
+		return event, err
+	}
+
+	err = c.SendWithAuth(req, event)
+	if err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
+
+// ResendWebhookEvent sends a webhook event once again, to the given webhooks or to all subscribed ones if webhookIDs is empty
+// Endpoint: POST /v1/notifications/webhooks-events/ID/resend
+func (c *Client) ResendWebhookEvent(eventID string, webhookIDs []string) (*WebhookEvent, error) {
+	return c.ResendWebhookEventContext(context.Background(), eventID, webhookIDs)
+}
+
+// ResendWebhookEventContext is like ResendWebhookEvent but uses ctx for the request
+func (c *Client) ResendWebhookEventContext(ctx context.Context, eventID string, webhookIDs []string) (*WebhookEvent, error) {
+	type resendRequest struct {
+		WebhookIDs []string `json:"webhook_ids,omitempty"`
+	}
+
+	event := &WebhookEvent{}
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/webhooks-events/"+eventID+"/resend"), resendRequest{WebhookIDs: webhookIDs})
+	if err != nil {
+		return event, err
+	}
+	setRequestID(req)
+
+	err = c.SendWithAuth(req, event)
+	if err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
+
+// listEventTypes returns event types from the given URL
+func (c *Client) listEventTypes(ctx context.Context, url string) ([]WebhookEventType, error) {
+	var types WebhookEventTypes
+
+	req, err := c.NewRequestContext(ctx, "GET", url, nil)
+	if err != nil {
+		return types.EventTypes, err
+	}
+
+	err = c.SendWithAuth(req, &types)
+	if err != nil {
+		return types.EventTypes, err
+	}
+
+	return types.EventTypes, nil
+}
+
+// ParseWebhookEvent decodes the body of a webhook notification.
+// The notification must be verified with a WebhookVerifier before it's trusted
+func ParseWebhookEvent(body []byte) (*WebhookEvent, error) {
+	event := &WebhookEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, err
+	}
+	if event.ID == "" || event.EventType == "" {
+		return nil, fmt.Errorf("paypalsdk: webhook event has no id or event_type")
+	}
+
+	return event, nil
+}
+
+// DecodeResource decodes Resource of the event by its EventType into
+// *Sale, *Refund, *Authorization, *Capture or *Agreement.
+// Resources of other event types are returned as json.RawMessage
+func (e *WebhookEvent) DecodeResource() (interface{}, error) {
+	var v interface{}
+
+	switch {
+	case strings.HasSuffix(e.EventType, ".REFUNDED") || strings.HasSuffix(e.EventType, ".REVERSED"):
+		v = &Refund{}
+	case strings.HasPrefix(e.EventType, "PAYMENT.SALE."):
+		v = &Sale{}
+	case strings.HasPrefix(e.EventType, "PAYMENT.AUTHORIZATION."):
+		v = &Authorization{}
+	case strings.HasPrefix(e.EventType, "PAYMENT.CAPTURE."):
+		v = &Capture{}
+	case strings.HasPrefix(e.EventType, "BILLING.SUBSCRIPTION."):
+		v = &Agreement{}
+	default:
+		return e.Resource, nil
+	}
+
+	if err := json.Unmarshal(e.Resource, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
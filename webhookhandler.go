@@ -0,0 +1,267 @@
+package paypalsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxWebhookBodySize limits the size of a notification read by WebhookHandler
+const maxWebhookBodySize = 1 << 20
+
+// WebhookHandlerFunc handles a verified webhook event.
+// A returned error makes PayPal deliver the event again later
+type WebhookHandlerFunc func(ctx context.Context, event *WebhookEvent) error
+
+// SeenEventStore records IDs of handled webhook events, so redelivered events are dropped
+type SeenEventStore interface {
+	// MarkSeen records the event and reports whether it was recorded before
+	MarkSeen(ctx context.Context, eventID string) (bool, error)
+	// Forget removes the event, so its next delivery is handled again
+	Forget(ctx context.Context, eventID string) error
+}
+
+// MemorySeenEventStore is a SeenEventStore which keeps event IDs in memory for TTL
+type MemorySeenEventStore struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	events  map[string]time.Time
+	sweptAt time.Time
+}
+
+// WebhookHandler is an http.Handler which verifies webhook notifications,
+// drops duplicates and dispatches events to the callbacks registered for their type.
+// It answers 200 when the event is handled, ignored or a duplicate, 400 when the notification is invalid
+// and 500 when verification or a callback failed, so PayPal retries the delivery
+type WebhookHandler struct {
+	Verifier   WebhookVerifier
+	SeenEvents SeenEventStore
+
+	handlers map[string]WebhookHandlerFunc
+	fallback WebhookHandlerFunc
+}
+
+// NewMemorySeenEventStore returns MemorySeenEventStore which remembers events for ttl.
+// PayPal retries failed deliveries for up to 3 days
+func NewMemorySeenEventStore(ttl time.Duration) *MemorySeenEventStore {
+	return &MemorySeenEventStore{TTL: ttl, events: make(map[string]time.Time)}
+}
+
+// NewWebhookHandler returns WebhookHandler which verifies notifications with v
+// and remembers handled events in memory for 3 days
+func NewWebhookHandler(v WebhookVerifier) *WebhookHandler {
+	return &WebhookHandler{
+		Verifier:   v,
+		SeenEvents: NewMemorySeenEventStore(72 * time.Hour),
+		handlers:   make(map[string]WebhookHandlerFunc),
+	}
+}
+
+// MarkSeen implements SeenEventStore
+func (s *MemorySeenEventStore) MarkSeen(ctx context.Context, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.events == nil {
+		s.events = make(map[string]time.Time)
+	}
+	// expired events are dropped at most once per TTL, so a delivery doesn't scan all of them
+	if now.Sub(s.sweptAt) > s.TTL {
+		for id, seenAt := range s.events {
+			if now.Sub(seenAt) > s.TTL {
+				delete(s.events, id)
+			}
+		}
+		s.sweptAt = now
+	}
+
+	if seenAt, ok := s.events[eventID]; ok && now.Sub(seenAt) <= s.TTL {
+		return true, nil
+	}
+	s.events[eventID] = now
+
+	return false, nil
+}
+
+// Forget implements SeenEventStore
+func (s *MemorySeenEventStore) Forget(ctx context.Context, eventID string) error {
+	s.mu.Lock()
+	delete(s.events, eventID)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// On registers fn for events of eventType, e.g. EventPaymentSaleCompleted
+func (h *WebhookHandler) On(eventType string, fn WebhookHandlerFunc) {
+	if h.handlers == nil {
+		h.handlers = make(map[string]WebhookHandlerFunc)
+	}
+	h.handlers[eventType] = fn
+}
+
+// OnUnhandled registers fn for events with no callback for their type, such events are acknowledged by default
+func (h *WebhookHandler) OnUnhandled(fn WebhookHandlerFunc) {
+	h.fallback = fn
+}
+
+// OnSaleCompleted registers fn for PAYMENT.SALE.COMPLETED events
+func (h *WebhookHandler) OnSaleCompleted(fn func(ctx context.Context, event *WebhookEvent, sale *Sale) error) {
+	h.On(EventPaymentSaleCompleted, func(ctx context.Context, event *WebhookEvent) error {
+		resource, err := event.DecodeResource()
+		if err != nil {
+			return err
+		}
+		sale, ok := resource.(*Sale)
+		if !ok {
+			return unexpectedResource(event, resource)
+		}
+		return fn(ctx, event, sale)
+	})
+}
+
+// OnSaleRefunded registers fn for PAYMENT.SALE.REFUNDED events
+func (h *WebhookHandler) OnSaleRefunded(fn func(ctx context.Context, event *WebhookEvent, refund *Refund) error) {
+	h.onRefund(EventPaymentSaleRefunded, fn)
+}
+
+// OnSaleReversed registers fn for PAYMENT.SALE.REVERSED events
+func (h *WebhookHandler) OnSaleReversed(fn func(ctx context.Context, event *WebhookEvent, refund *Refund) error) {
+	h.onRefund(EventPaymentSaleReversed, fn)
+}
+
+// OnCaptureCompleted registers fn for PAYMENT.CAPTURE.COMPLETED events
+func (h *WebhookHandler) OnCaptureCompleted(fn func(ctx context.Context, event *WebhookEvent, capture *Capture) error) {
+	h.On(EventPaymentCaptureCompleted, func(ctx context.Context, event *WebhookEvent) error {
+		resource, err := event.DecodeResource()
+		if err != nil {
+			return err
+		}
+		capture, ok := resource.(*Capture)
+		if !ok {
+			return unexpectedResource(event, resource)
+		}
+		return fn(ctx, event, capture)
+	})
+}
+
+// OnCaptureRefunded registers fn for PAYMENT.CAPTURE.REFUNDED events
+func (h *WebhookHandler) OnCaptureRefunded(fn func(ctx context.Context, event *WebhookEvent, refund *Refund) error) {
+	h.onRefund(EventPaymentCaptureRefunded, fn)
+}
+
+// OnBillingSubscriptionCreated registers fn for BILLING.SUBSCRIPTION.CREATED events
+func (h *WebhookHandler) OnBillingSubscriptionCreated(fn func(ctx context.Context, event *WebhookEvent, agreement *Agreement) error) {
+	h.onAgreement(EventBillingSubscriptionCreated, fn)
+}
+
+// OnBillingSubscriptionCancelled registers fn for BILLING.SUBSCRIPTION.CANCELLED events
+func (h *WebhookHandler) OnBillingSubscriptionCancelled(fn func(ctx context.Context, event *WebhookEvent, agreement *Agreement) error) {
+	h.onAgreement(EventBillingSubscriptionCancelled, fn)
+}
+
+// OnBillingSubscriptionSuspended registers fn for BILLING.SUBSCRIPTION.SUSPENDED events
+func (h *WebhookHandler) OnBillingSubscriptionSuspended(fn func(ctx context.Context, event *WebhookEvent, agreement *Agreement) error) {
+	h.onAgreement(EventBillingSubscriptionSuspended, fn)
+}
+
+// ServeHTTP implements http.Handler
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = h.Verifier.VerifyWebhook(r.Context(), r.Header, body); err != nil {
+		if errors.Is(err, ErrInvalidWebhookSignature) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	event, err := ParseWebhookEvent(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fn, ok := h.handlers[event.EventType]
+	if !ok {
+		fn = h.fallback
+	}
+	if fn == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if h.SeenEvents != nil {
+		seen, err := h.SeenEvents.MarkSeen(r.Context(), event.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if seen {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	if err = fn(r.Context(), event); err != nil {
+		if h.SeenEvents != nil {
+			h.SeenEvents.Forget(r.Context(), event.ID)
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// onRefund registers fn for events with a refund resource
+func (h *WebhookHandler) onRefund(eventType string, fn func(ctx context.Context, event *WebhookEvent, refund *Refund) error) {
+	h.On(eventType, func(ctx context.Context, event *WebhookEvent) error {
+		resource, err := event.DecodeResource()
+		if err != nil {
+			return err
+		}
+		refund, ok := resource.(*Refund)
+		if !ok {
+			return unexpectedResource(event, resource)
+		}
+		return fn(ctx, event, refund)
+	})
+}
+
+// onAgreement registers fn for events with an agreement resource
+func (h *WebhookHandler) onAgreement(eventType string, fn func(ctx context.Context, event *WebhookEvent, agreement *Agreement) error) {
+	h.On(eventType, func(ctx context.Context, event *WebhookEvent) error {
+		resource, err := event.DecodeResource()
+		if err != nil {
+			return err
+		}
+		agreement, ok := resource.(*Agreement)
+		if !ok {
+			return unexpectedResource(event, resource)
+		}
+		return fn(ctx, event, agreement)
+	})
+}
+
+// unexpectedResource returns an error for a resource of event which is not of the expected type
+func unexpectedResource(event *WebhookEvent, resource interface{}) error {
+	return fmt.Errorf("paypalsdk: unexpected resource %T of %s event", resource, event.EventType)
+}
@@ -0,0 +1,335 @@
+package paypalsdk
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidWebhookSignature is returned by a WebhookVerifier when the notification is not signed by PayPal
+var ErrInvalidWebhookSignature = errors.New("paypalsdk: invalid webhook signature")
+
+// Headers sent by PayPal with every webhook notification
+const (
+	HeaderTransmissionID   = "PAYPAL-TRANSMISSION-ID"
+	HeaderTransmissionTime = "PAYPAL-TRANSMISSION-TIME"
+	HeaderTransmissionSig  = "PAYPAL-TRANSMISSION-SIG"
+	HeaderCertURL          = "PAYPAL-CERT-URL"
+	HeaderAuthAlgo         = "PAYPAL-AUTH-ALGO"
+)
+
+// maxCertSize is the largest certificate chain HTTPCertFetcher downloads
+const maxCertSize = 64 << 10
+
+// defaultTransmissionTolerance is MaxTransmissionAge of verifiers returned by NewLocalWebhookVerifier
+const defaultTransmissionTolerance = 10 * time.Minute
+
+// WebhookVerifier checks that a webhook notification with the given headers and raw body was sent by PayPal
+type WebhookVerifier interface {
+	VerifyWebhook(ctx context.Context, header http.Header, body []byte) error
+}
+
+// CertFetcher downloads and validates the certificate PayPal signs notifications with
+type CertFetcher interface {
+	FetchCert(ctx context.Context, certURL string) (*x509.Certificate, error)
+}
+
+// CertCache keeps certificates by their URL, so they are not downloaded for every notification
+type CertCache interface {
+	GetCert(certURL string) (*x509.Certificate, bool)
+	SetCert(certURL string, cert *x509.Certificate)
+}
+
+// LocalWebhookVerifier verifies the signature of notifications without calling the API.
+// PayPal signs "<transmission id>|<transmission time>|<webhook id>|<CRC32 of body>"
+// with the key of the certificate at PAYPAL-CERT-URL
+type LocalWebhookVerifier struct {
+	WebhookID   string
+	CertFetcher CertFetcher
+	CertCache   CertCache
+	// MaxTransmissionAge rejects notifications whose PAYPAL-TRANSMISSION-TIME is further from now,
+	// so a captured notification can't be replayed later. Zero disables the check
+	MaxTransmissionAge time.Duration
+}
+
+// RemoteWebhookVerifier verifies notifications by calling the API
+type RemoteWebhookVerifier struct {
+	Client    *Client
+	WebhookID string
+}
+
+// HTTPCertFetcher is a CertFetcher which downloads certificates from paypal.com over HTTPS
+// and verifies their chain against Roots (system roots if nil)
+type HTTPCertFetcher struct {
+	Client *http.Client
+	Roots  *x509.CertPool
+}
+
+// MemoryCertCache is a CertCache which keeps certificates in memory until they expire
+type MemoryCertCache struct {
+	mu    sync.RWMutex
+	certs map[string]*x509.Certificate
+}
+
+// VerifyWebhookResponse is the response of POST /v1/notifications/verify-webhook-signature
+type VerifyWebhookResponse struct {
+	VerificationStatus string `json:"verification_status"`
+}
+
+// NewLocalWebhookVerifier returns LocalWebhookVerifier which downloads certificates with HTTPCertFetcher,
+// caches them in memory and accepts notifications transmitted within 10 minutes from now
+func NewLocalWebhookVerifier(webhookID string) *LocalWebhookVerifier {
+	return &LocalWebhookVerifier{
+		WebhookID:          webhookID,
+		CertFetcher:        &HTTPCertFetcher{},
+		CertCache:          NewMemoryCertCache(),
+		MaxTransmissionAge: defaultTransmissionTolerance,
+	}
+}
+
+// NewRemoteWebhookVerifier returns RemoteWebhookVerifier which verifies notifications with c
+func NewRemoteWebhookVerifier(c *Client, webhookID string) *RemoteWebhookVerifier {
+	return &RemoteWebhookVerifier{Client: c, WebhookID: webhookID}
+}
+
+// NewMemoryCertCache returns new MemoryCertCache
+func NewMemoryCertCache() *MemoryCertCache {
+	return &MemoryCertCache{certs: make(map[string]*x509.Certificate)}
+}
+
+// VerifyWebhook implements WebhookVerifier
+func (v *LocalWebhookVerifier) VerifyWebhook(ctx context.Context, header http.Header, body []byte) error {
+	for _, h := range []string{HeaderTransmissionID, HeaderTransmissionTime, HeaderTransmissionSig, HeaderCertURL} {
+		if header.Get(h) == "" {
+			return fmt.Errorf("%w: missing %s header", ErrInvalidWebhookSignature, h)
+		}
+	}
+	if algo := header.Get(HeaderAuthAlgo); algo != "" && algo != "SHA256withRSA" {
+		return fmt.Errorf("%w: unsupported %s %s", ErrInvalidWebhookSignature, HeaderAuthAlgo, algo)
+	}
+
+	if v.MaxTransmissionAge > 0 {
+		sent, err := time.Parse(time.RFC3339, header.Get(HeaderTransmissionTime))
+		if err != nil {
+			return fmt.Errorf("%w: invalid %s header", ErrInvalidWebhookSignature, HeaderTransmissionTime)
+		}
+		if age := time.Since(sent); age > v.MaxTransmissionAge || age < -v.MaxTransmissionAge {
+			return fmt.Errorf("%w: transmitted at %s", ErrInvalidWebhookSignature, header.Get(HeaderTransmissionTime))
+		}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(header.Get(HeaderTransmissionSig))
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+
+	cert, err := v.cert(ctx, header.Get(HeaderCertURL))
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("%s|%s|%s|%d", header.Get(HeaderTransmissionID), header.Get(HeaderTransmissionTime), v.WebhookID, crc32.ChecksumIEEE(body))
+	if err = cert.CheckSignature(x509.SHA256WithRSA, []byte(msg), sig); err != nil {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+// cert returns the certificate from the cache or from the fetcher
+func (v *LocalWebhookVerifier) cert(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	if v.CertCache != nil {
+		if cert, ok := v.CertCache.GetCert(certURL); ok {
+			return cert, nil
+		}
+	}
+
+	fetcher := v.CertFetcher
+	if fetcher == nil {
+		fetcher = &HTTPCertFetcher{}
+	}
+	cert, err := fetcher.FetchCert(ctx, certURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.CertCache != nil {
+		v.CertCache.SetCert(certURL, cert)
+	}
+
+	return cert, nil
+}
+
+// VerifyWebhook implements WebhookVerifier
+func (v *RemoteWebhookVerifier) VerifyWebhook(ctx context.Context, header http.Header, body []byte) error {
+	res, err := v.Client.VerifyWebhookSignatureContext(ctx, header, body, v.WebhookID)
+	if err != nil {
+		return err
+	}
+	if res.VerificationStatus != "SUCCESS" {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+// VerifyWebhookSignature asks PayPal to verify a webhook notification with the given headers and raw body
+// Endpoint: POST /v1/notifications/verify-webhook-signature
+func (c *Client) VerifyWebhookSignature(header http.Header, body []byte, webhookID string) (*VerifyWebhookResponse, error) {
+	return c.VerifyWebhookSignatureContext(context.Background(), header, body, webhookID)
+}
+
+// VerifyWebhookSignatureContext is like VerifyWebhookSignature but uses ctx for the request
+func (c *Client) VerifyWebhookSignatureContext(ctx context.Context, header http.Header, body []byte, webhookID string) (*VerifyWebhookResponse, error) {
+	type verifyRequest struct {
+		AuthAlgo         string          `json:"auth_algo"`
+		CertURL          string          `json:"cert_url"`
+		TransmissionID   string          `json:"transmission_id"`
+		TransmissionSig  string          `json:"transmission_sig"`
+		TransmissionTime string          `json:"transmission_time"`
+		WebhookID        string          `json:"webhook_id"`
+		WebhookEvent     json.RawMessage `json:"webhook_event"`
+	}
+
+	if !json.Valid(body) {
+		return &VerifyWebhookResponse{}, errors.New("paypalsdk: webhook body is not a valid JSON")
+	}
+
+	response := &VerifyWebhookResponse{}
+
+	req, err := c.NewRequestContext(ctx, "POST", fmt.Sprintf("%s%s", c.APIBase, "/v1/notifications/verify-webhook-signature"), verifyRequest{
+		AuthAlgo:         header.Get(HeaderAuthAlgo),
+		CertURL:          header.Get(HeaderCertURL),
+		TransmissionID:   header.Get(HeaderTransmissionID),
+		TransmissionSig:  header.Get(HeaderTransmissionSig),
+		TransmissionTime: header.Get(HeaderTransmissionTime),
+		WebhookID:        webhookID,
+		WebhookEvent:     body,
+	})
+	if err != nil {
+		return response, err
+	}
+
+	err = c.SendWithAuth(req, response)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// FetchCert implements CertFetcher
+// Chains over 64 KiB are rejected.
+// Only https URLs of paypal.com are accepted, so a forged notification can't point to a certificate of its own
+func (f *HTTPCertFetcher) FetchCert(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(strings.ToLower(u.Hostname()), ".paypal.com") {
+		return nil, fmt.Errorf("paypalsdk: certificate URL %s is not a paypal.com HTTPS URL", certURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := f.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("paypalsdk: unable to fetch certificate %s: %d", certURL, resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCertSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCertSize {
+		return nil, fmt.Errorf("paypalsdk: certificate %s is larger than %d bytes", certURL, maxCertSize)
+	}
+
+	return parseCertChain(data, f.Roots)
+}
+
+// GetCert implements CertCache, expired certificates are never returned
+func (cc *MemoryCertCache) GetCert(certURL string) (*x509.Certificate, bool) {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+
+	cert, ok := cc.certs[certURL]
+	if !ok || time.Now().After(cert.NotAfter) {
+		return nil, false
+	}
+
+	return cert, true
+}
+
+// SetCert implements CertCache
+func (cc *MemoryCertCache) SetCert(certURL string, cert *x509.Certificate) {
+	cc.mu.Lock()
+	cc.certs[certURL] = cert
+	cc.mu.Unlock()
+}
+
+// parseCertChain parses PEM encoded certificates, the first one is the signing certificate,
+// the others are intermediates used to verify it against roots
+func parseCertChain(data []byte, roots *x509.CertPool) (*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("paypalsdk: no certificate found")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	leaf := certs[0]
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(leaf.Subject.CommonName), ".paypal.com") {
+		return nil, fmt.Errorf("paypalsdk: certificate %s is not issued to paypal.com", leaf.Subject.CommonName)
+	}
+
+	return leaf, nil
+}
@@ -1,6 +1,7 @@
 package paypalsdk
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
@@ -11,8 +12,13 @@ import (
 //
 // Endpoint: POST /v1/payment-experience/web-profiles
 func (c *Client) CreateWebProfile(wp WebProfile) (*WebProfile, error) {
+	return c.CreateWebProfileContext(context.Background(), wp)
+}
+
+// CreateWebProfileContext is like CreateWebProfile but uses ctx for the request
+func (c *Client) CreateWebProfileContext(ctx context.Context, wp WebProfile) (*WebProfile, error) {
 	url := fmt.Sprintf("%s%s", c.APIBase, "/v1/payment-experience/web-profiles")
-	req, err := c.NewRequest("POST", url, wp)
+	req, err := c.NewRequestContext(ctx, "POST", url, wp)
 	if err != nil {
 		return &WebProfile{}, err
 	}
@@ -31,10 +37,15 @@ func (c *Client) CreateWebProfile(wp WebProfile) (*WebProfile, error) {
 //
 // Endpoint: GET /v1/payment-experience/web-profiles/<profile-id>
 func (c *Client) GetWebProfile(profileID string) (*WebProfile, error) {
+	return c.GetWebProfileContext(context.Background(), profileID)
+}
+
+// GetWebProfileContext is like GetWebProfile but uses ctx for the request
+func (c *Client) GetWebProfileContext(ctx context.Context, profileID string) (*WebProfile, error) {
 	var wp WebProfile
 
 	url := fmt.Sprintf("%s%s%s", c.APIBase, "/v1/payment-experience/web-profiles/", profileID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	if err != nil {
 		return &wp, err
@@ -56,10 +67,15 @@ func (c *Client) GetWebProfile(profileID string) (*WebProfile, error) {
 //
 // Endpoint: GET /v1/payment-experience/web-profiles
 func (c *Client) GetWebProfiles() ([]WebProfile, error) {
+	return c.GetWebProfilesContext(context.Background())
+}
+
+// GetWebProfilesContext is like GetWebProfiles but uses ctx for the request
+func (c *Client) GetWebProfilesContext(ctx context.Context) ([]WebProfile, error) {
 	var wps []WebProfile
 
 	url := fmt.Sprintf("%s%s", c.APIBase, "/v1/payment-experience/web-profiles")
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	if err != nil {
 		return wps, err
@@ -77,6 +93,11 @@ func (c *Client) GetWebProfiles() ([]WebProfile, error) {
 //
 // Endpoint: PUT /v1/payment-experience/web-profiles
 func (c *Client) SetWebProfile(wp WebProfile) error {
+	return c.SetWebProfileContext(context.Background(), wp)
+}
+
+// SetWebProfileContext is like SetWebProfile but uses ctx for the request
+func (c *Client) SetWebProfileContext(ctx context.Context, wp WebProfile) error {
 
 	if wp.ID == "" {
 		return fmt.Errorf("paypalsdk: no ID specified for WebProfile")
@@ -84,7 +105,7 @@ func (c *Client) SetWebProfile(wp WebProfile) error {
 
 	url := fmt.Sprintf("%s%s%s", c.APIBase, "/v1/payment-experience/web-profiles/", wp.ID)
 
-	req, err := c.NewRequest("PUT", url, wp)
+	req, err := c.NewRequestContext(ctx, "PUT", url, wp)
 
 	if err != nil {
 		return err
@@ -102,10 +123,15 @@ func (c *Client) SetWebProfile(wp WebProfile) error {
 //
 // Endpoint: DELETE /v1/payment-experience/web-profiles
 func (c *Client) DeleteWebProfile(profileID string) error {
+	return c.DeleteWebProfileContext(context.Background(), profileID)
+}
+
+// DeleteWebProfileContext is like DeleteWebProfile but uses ctx for the request
+func (c *Client) DeleteWebProfileContext(ctx context.Context, profileID string) error {
 
 	url := fmt.Sprintf("%s%s%s", c.APIBase, "/v1/payment-experience/web-profiles/", profileID)
 
-	req, err := c.NewRequest("DELETE", url, nil)
+	req, err := c.NewRequestContext(ctx, "DELETE", url, nil)
 
 	if err != nil {
 		return err